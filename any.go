@@ -1,6 +1,7 @@
 package rs
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
@@ -95,16 +96,23 @@ func (o *AnyObject) UnmarshalYAML(n *yaml.Node) error {
 	case yaml.SequenceNode:
 		o.kind = _sliceData
 		o.sliceData = make([]AnyObject, len(n.Content))
+
+		var errs []error
 		for i, vn := range n.Content {
 			_ = Init(&o.sliceData[i], o._opts)
 
 			err := prepareYamlNode(vn).Decode(&o.sliceData[i])
 			if err != nil {
-				return err
+				err = newError(o._parentValue.Type(), errorPathIndex(i), vn, "", err)
+				if !o.collectAllErrors() {
+					return err
+				}
+
+				errs = append(errs, err)
 			}
 		}
 
-		return nil
+		return joinErrors(errs)
 	case yaml.MappingNode:
 		o.kind = _mapData
 		pairs, err := unmarshalYamlMap(n.Content)
@@ -116,7 +124,7 @@ func (o *AnyObject) UnmarshalYAML(n *yaml.Node) error {
 		for _, pair := range pairs {
 			if suffix := strings.TrimPrefix(pair[0].Value, "__@"); suffix != pair[0].Value {
 				// is virtual key
-				err = o.BaseField.addUnresolvedField_self(suffix, pair[1])
+				err = o.BaseField.addUnresolvedField_self(suffix, pair[0], pair[1])
 				if err != nil {
 					return err
 				}
@@ -156,12 +164,21 @@ func (o *AnyObject) UnmarshalYAML(n *yaml.Node) error {
 }
 
 func (o *AnyObject) ResolveFields(rc RenderingHandler, depth int, names ...string) error {
+	return o.ResolveFieldsContext(context.Background(), rc, depth, names...)
+}
+
+func (o *AnyObject) ResolveFieldsContext(
+	ctx context.Context,
+	rc RenderingHandler,
+	depth int,
+	names ...string,
+) error {
 	if o == nil {
 		return nil
 	}
 
 	// resolve self (__@)
-	err := o.BaseField.ResolveFields(rc, depth)
+	err := o.BaseField.ResolveFieldsContext(ctx, rc, depth)
 	if err != nil {
 		return err
 	}
@@ -172,22 +189,30 @@ func (o *AnyObject) ResolveFields(rc RenderingHandler, depth int, names ...strin
 			Init(&o.mapData, o._opts)
 		}
 
-		return o.mapData.ResolveFields(rc, depth, names...)
+		return o.mapData.ResolveFieldsContext(ctx, rc, depth, names...)
 	case _sliceData:
-		n := len(o.sliceData)
+		var (
+			n    = len(o.sliceData)
+			errs []error
+		)
 		for i := 0; i < n; i++ {
 			sd := &o.sliceData[i]
 			if !sd.initialized() {
 				Init(sd, o._opts)
 			}
 
-			err := sd.ResolveFields(rc, depth, names...)
+			err := sd.ResolveFieldsContext(ctx, rc, depth, names...)
 			if err != nil {
-				return err
+				prefixErrorPath(errorPathIndex(i), err)
+				if !o.collectAllErrors() {
+					return err
+				}
+
+				errs = append(errs, err)
 			}
 		}
 
-		return nil
+		return joinErrors(errs)
 	default:
 		// scalar type data doesn't need resolving
 		return nil
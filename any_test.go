@@ -12,6 +12,7 @@ import (
 // type check
 var (
 	_ Field          = (*AnyObject)(nil)
+	_ ContextField   = (*AnyObject)(nil)
 	_ yaml.Marshaler = (*AnyObject)(nil)
 	_ json.Marshaler = (*AnyObject)(nil)
 
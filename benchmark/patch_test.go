@@ -0,0 +1,150 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+)
+
+type PatchFoo struct {
+	rs.BaseField
+
+	Items []PatchItem `yaml:"items"`
+}
+
+type PatchItem struct {
+	rs.BaseField
+
+	Name   string   `yaml:"name"`
+	Weight float64  `yaml:"weight"`
+	Env    []string `yaml:"env"`
+}
+
+func runPatchSpecBenchmark(b *testing.B, src []byte, cacheSize int) {
+	rs.SetJQCacheSize(cacheSize)
+	defer rs.SetJQCacheSize(rs.DefaultJQCacheSize)
+
+	rc := rs.RenderingHandleFunc(func(renderer string, rawData any) (result []byte, err error) {
+		return nil, fmt.Errorf("unexpected renderer %q", renderer)
+	})
+
+	var (
+		f   PatchFoo
+		err error
+	)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = rs.Init(&f, nil)
+		if err = yaml.Unmarshal(src, &f); err != nil {
+			b.Log(err)
+			b.Fail()
+		}
+
+		if err = f.ResolveFields(rc, -1); err != nil {
+			b.Log(err)
+			b.Fail()
+		}
+
+		if len(f.Items) != 100 || len(f.Items[0].Env) != 2 {
+			b.Fail()
+		}
+	}
+}
+
+func BenchmarkPatchSpec_select(b *testing.B) {
+	// the same patch spec repeated in list items
+	item := `
+- env@!:
+    value: [A=1]
+    merge:
+    - value: [{ name: B, value: "2" }, { name: C, value: "3" }]
+      select: map(select(.name != "C") | .name + "=" + .value)
+    select: map(ascii_upcase)
+`
+
+	src := []byte("items:" + strings.Repeat(item, 100))
+
+	b.Run("cached", func(b *testing.B) {
+		runPatchSpecBenchmark(b, src, rs.DefaultJQCacheSize)
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		runPatchSpecBenchmark(b, src, 0)
+	})
+}
+
+func BenchmarkUniqueList(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		list := make([]any, n)
+		for i := range list {
+			// half of the items are duplicates
+			list[i] = map[string]any{
+				"name":  fmt.Sprint("item-", i%(n/2)),
+				"value": []any{i % (n / 2), "x"},
+			}
+		}
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if len(rs.UniqueList(list)) != n/2 {
+					b.Fail()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPatchSpec_convert(b *testing.B) {
+	// patched data converted back to yaml for the resolved field
+	var sb strings.Builder
+	sb.WriteString("items@!:\n  value:\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, "  - { name: item-%d, weight: %d.5, env: [A=%d, B=%d] }\n", i, i, i, i)
+	}
+
+	merge := sb.String() + "  merge:\n  - value: [{ name: extra, weight: 1.0, env: [C=1] }]\n"
+	patch := sb.String() + "  patch:\n  - { op: add, path: /-, value: { name: extra, weight: 1.0, env: [C=1] } }\n"
+
+	rc := rs.RenderingHandleFunc(func(renderer string, rawData any) (result []byte, err error) {
+		return nil, fmt.Errorf("unexpected renderer %q", renderer)
+	})
+
+	for _, test := range []struct {
+		name string
+		src  []byte
+	}{
+		{"merge", []byte(merge)},
+		{"patch", []byte(patch)},
+	} {
+		b.Run(test.name, func(b *testing.B) {
+			var (
+				f   PatchFoo
+				err error
+			)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = rs.Init(&f, nil)
+				if err = yaml.Unmarshal(test.src, &f); err != nil {
+					b.Log(err)
+					b.Fail()
+				}
+
+				if err = f.ResolveFields(rc, -1); err != nil {
+					b.Log(err)
+					b.Fail()
+				}
+
+				if len(f.Items) != 101 {
+					b.Fail()
+				}
+			}
+		})
+	}
+}
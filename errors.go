@@ -2,6 +2,12 @@ package rs
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -14,3 +20,236 @@ var (
 	// as expected typed
 	ErrInterfaceTypeNotHandled = errors.New("interface type not handled")
 )
+
+// Error is an error with the location of the yaml key causing it
+//
+// errors returned from BaseField.UnmarshalYAML, BaseField.ResolveFields and PatchSpec.Apply
+// contain an *Error when the location is known, use errors.As to retrieve it
+type Error struct {
+	// Type of the struct the yaml key belongs to
+	//
+	// can be nil when unknown
+	Type reflect.Type
+
+	// Path of the yaml key, without rendering suffix
+	//
+	// it is the full path from the struct being unmarshaled or resolved, in JSONPath-like
+	// dotted form (e.g. `tools.golang[3].cmd`), keys containing `.`, `[` or `]` are quoted
+	// in brackets (e.g. `env["a.b"]`)
+	Path string
+
+	// Line and Column of the yaml key in the source document
+	//
+	// they are 0 when unknown (e.g. the yaml node was not parsed from text)
+	Line   int
+	Column int
+
+	// Renderer is the name of the renderer failed
+	//
+	// empty when the error is not caused by any renderer
+	Renderer string
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Path)
+
+	if e.Line != 0 {
+		if sb.Len() != 0 {
+			sb.WriteString(" ")
+		}
+
+		fmt.Fprintf(&sb, "(line %d, column %d)", e.Line, e.Column)
+	}
+
+	if sb.Len() != 0 {
+		sb.WriteString(": ")
+	}
+
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError wraps err as an *Error located at the yaml node key
+//
+// when err already contains *Error(s), which are closer to the source, path is prepended to
+// their Path and err is returned as is
+func newError(typ reflect.Type, path string, key *yaml.Node, renderer string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if prefixErrorPath(path, err) {
+		return err
+	}
+
+	e := &Error{
+		Type:     typ,
+		Path:     path,
+		Renderer: renderer,
+		Err:      err,
+	}
+
+	if key != nil {
+		e.Line, e.Column = key.Line, key.Column
+	}
+
+	return e
+}
+
+// prefixErrorPath prepends path to the Path of every *Error found in err,
+// it reports whether there is any *Error
+func prefixErrorPath(path string, err error) (found bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case *Error:
+			e.Path = joinErrorPath(path, e.Path)
+			return true
+		case errorList:
+			for _, item := range e {
+				if prefixErrorPath(path, item) {
+					found = true
+				}
+			}
+
+			return found
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return false
+}
+
+// wrapErrorf is like fmt.Errorf(format+": %w", a..., err), but keeps *Error(s) in err at the top
+// level, so the path prepended to them later is still reflected in the error message
+func wrapErrorf(err error, format string, a ...any) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		e.Err = wrapErrorf(e.Err, format, a...)
+		return e
+	case errorList:
+		for i := range e {
+			e[i] = wrapErrorf(e[i], format, a...)
+		}
+
+		return e
+	default:
+		return fmt.Errorf(format+": %w", append(a, err)...)
+	}
+}
+
+// joinErrorPath joins parent and child path of an *Error
+func joinErrorPath(parent, child string) string {
+	switch {
+	case len(parent) == 0:
+		return child
+	case len(child) == 0:
+		return parent
+	case child[0] == '[':
+		return parent + child
+	default:
+		return parent + "." + child
+	}
+}
+
+// errorPathKey formats yaml key k as a segment of the *Error path
+func errorPathKey(k string) string {
+	if len(k) == 0 || strings.ContainsAny(k, ".[]") {
+		return "[" + strconv.Quote(k) + "]"
+	}
+
+	return k
+}
+
+// errorPathIndex formats list index i as a segment of the *Error path
+func errorPathIndex(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+// errorList is a list of errors in deterministic order
+type errorList []error
+
+func (l errorList) Error() string {
+	var sb strings.Builder
+	for i, err := range l {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString(err.Error())
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns all errors in the list, for errors.Is and errors.As
+func (l errorList) Unwrap() []error { return l }
+
+// Is reports whether any error in the list matches target, errors.Is only follows
+// Unwrap() []error since go1.20
+func (l errorList) Is(target error) bool {
+	for _, err := range l {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error in the list that matches target, errors.As only follows
+// Unwrap() []error since go1.20
+func (l errorList) As(target any) bool {
+	for _, err := range l {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyedErrors sorts errors by their keys
+type keyedErrors struct {
+	keys []string
+	errs []error
+}
+
+func (e *keyedErrors) Len() int           { return len(e.keys) }
+func (e *keyedErrors) Less(i, j int) bool { return e.keys[i] < e.keys[j] }
+func (e *keyedErrors) Swap(i, j int) {
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+	e.errs[i], e.errs[j] = e.errs[j], e.errs[i]
+}
+
+// joinErrors returns nil when there is no error in errs, the only error
+// when there is one, otherwise an errorList
+func joinErrors(errs []error) error {
+	var ret errorList
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case errorList:
+			// flatten nested lists
+			ret = append(ret, e...)
+		default:
+			ret = append(ret, err)
+		}
+	}
+
+	switch len(ret) {
+	case 0:
+		return nil
+	case 1:
+		return ret[0]
+	default:
+		return ret
+	}
+}
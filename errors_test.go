@@ -0,0 +1,315 @@
+package rs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestError(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Str   string            `yaml:"str"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	typeFoo := reflect.TypeOf(Foo{})
+
+	for _, test := range []struct {
+		name  string
+		input string
+		opts  *Options
+
+		unmarshalErr bool
+		expected     Error
+	}{
+		{
+			name:         "Renderer Not Allowed",
+			input:        "str: a\nother@echo: b",
+			opts:         &Options{AllowedRenderers: map[string]struct{}{}},
+			unmarshalErr: true,
+			expected: Error{
+				Type:     typeFoo,
+				Path:     "other",
+				Line:     2,
+				Column:   1,
+				Renderer: "echo",
+			},
+		},
+		{
+			name:         "Invalid Data",
+			input:        "str: [a]",
+			unmarshalErr: true,
+			expected: Error{
+				Type:   typeFoo,
+				Path:   "str",
+				Line:   1,
+				Column: 1,
+			},
+		},
+		{
+			name:  "Renderer Failed",
+			input: "str: a\n  \nstr@echo|err: b",
+			expected: Error{
+				Type:     typeFoo,
+				Path:     "str",
+				Line:     3,
+				Column:   1,
+				Renderer: "err",
+			},
+		},
+		{
+			name:  "Inline Map Item Renderer Failed",
+			input: "{ str: a, other@err: b }",
+			expected: Error{
+				Type:     typeFoo,
+				Path:     "other",
+				Line:     1,
+				Column:   11,
+				Renderer: "err",
+			},
+		},
+		{
+			name:  "Patch Spec Failed",
+			input: "str@!:\n  value: foo\n  merge:\n  - value: [bar]",
+			expected: Error{
+				Type:   typeStruct_PatchSpec,
+				Path:   "str.merge[0]",
+				Line:   4,
+				Column: 12,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := Init(&Foo{}, test.opts).(*Foo)
+
+			err := yaml.Unmarshal([]byte(test.input), f)
+			if !test.unmarshalErr {
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				err = f.ResolveFields(testRenderingHandler{}, -1)
+			}
+
+			var e *Error
+			if !assert.True(t, errors.As(err, &e), err) {
+				return
+			}
+
+			t.Log(err)
+
+			assert.Equal(t, test.expected.Type, e.Type)
+			assert.Equal(t, test.expected.Path, e.Path)
+			assert.Equal(t, test.expected.Line, e.Line)
+			assert.Equal(t, test.expected.Column, e.Column)
+			assert.Equal(t, test.expected.Renderer, e.Renderer)
+			assert.Error(t, e.Err)
+		})
+	}
+}
+
+func TestError_Path(t *testing.T) {
+	type Tool struct {
+		BaseField
+
+		Cmd string `yaml:"cmd"`
+	}
+
+	type Tools struct {
+		BaseField
+
+		Golang []Tool           `yaml:"golang"`
+		Env    map[string]*Tool `yaml:"env"`
+	}
+
+	type Root struct {
+		BaseField
+
+		Tools Tools `yaml:"tools"`
+	}
+
+	for _, test := range []struct {
+		name  string
+		input string
+
+		unmarshalErr bool
+		path         string
+		line         int
+	}{
+		{
+			name:         "Unmarshal List Item",
+			input:        "tools:\n  golang:\n  - cmd: a\n  - cmd: [b]",
+			unmarshalErr: true,
+			path:         "tools.golang[1].cmd",
+			line:         4,
+		},
+		{
+			name:         "Unmarshal Map Item",
+			input:        "tools:\n  env:\n    a.b:\n      cmd: [b]",
+			unmarshalErr: true,
+			path:         `tools.env["a.b"].cmd`,
+			line:         4,
+		},
+		{
+			name:  "Resolve List Item",
+			input: "tools:\n  golang:\n  - cmd: a\n  - cmd@err: b",
+			path:  "tools.golang[1].cmd",
+			line:  4,
+		},
+		{
+			name:  "Resolve Map Item",
+			input: "tools:\n  env:\n    a.b:\n      cmd@err: b",
+			path:  `tools.env["a.b"].cmd`,
+			line:  4,
+		},
+		{
+			name:  "Resolve Rendered List Item",
+			input: "tools:\n  golang@echo:\n  - cmd: a\n  - cmd@err: b",
+			path:  "tools.golang[1].cmd",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := Init(&Root{}, nil).(*Root)
+
+			err := yaml.Unmarshal([]byte(test.input), f)
+			if !test.unmarshalErr {
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				err = f.ResolveFields(testRenderingHandler{}, -1)
+			}
+
+			var e *Error
+			if !assert.True(t, errors.As(err, &e), err) {
+				return
+			}
+
+			t.Log(err)
+
+			assert.Equal(t, test.path, e.Path)
+			assert.True(t, strings.HasPrefix(err.Error(), test.path+" "), err.Error())
+			if test.line != 0 {
+				assert.Equal(t, test.line, e.Line)
+			}
+		})
+	}
+}
+
+func TestOptions_CollectAllErrors(t *testing.T) {
+	type Item struct {
+		BaseField
+
+		Cmd string `yaml:"cmd"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Str   string `yaml:"str"`
+		Items []Item `yaml:"items"`
+	}
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		f := Init(&Foo{}, &Options{
+			CollectAllErrors: true,
+			AllowedRenderers: map[string]struct{}{"echo": {}},
+		}).(*Foo)
+
+		err := yaml.Unmarshal([]byte(`str@err: a
+unknown: b
+items:
+- cmd: [x]
+- cmd: ok
+- cmd: [y]`), f)
+		if !assert.Error(t, err) {
+			return
+		}
+
+		t.Log(err)
+
+		msg := err.Error()
+		last := -1
+		for _, path := range []string{
+			"str (line 1,", "unknown (line 2,", "items[0].cmd (line 4,", "items[2].cmd (line 6,",
+		} {
+			idx := strings.Index(msg, path)
+			assert.Greater(t, idx, last, path)
+			last = idx
+		}
+
+		assert.Equal(t, "ok", f.Items[1].Cmd)
+	})
+
+	for _, concurrency := range []int{0, 2} {
+		t.Run(fmt.Sprintf("Resolve-%d", concurrency), func(t *testing.T) {
+			f := Init(&Foo{}, &Options{
+				CollectAllErrors:     true,
+				RenderingConcurrency: concurrency,
+			}).(*Foo)
+
+			assert.NoError(t, yaml.Unmarshal([]byte(`str@echo?int: a
+items:
+- cmd@err: x
+- cmd@echo: ok
+- cmd@err: y`), f))
+
+			err := f.ResolveFields(testRenderingHandler{}, -1)
+			if !assert.Error(t, err) {
+				return
+			}
+
+			t.Log(err)
+
+			msg := err.Error()
+			last := -1
+			for _, path := range []string{
+				"items[0].cmd (line 3,", "items[2].cmd (line 5,", "str (line 1,",
+			} {
+				idx := strings.Index(msg, path)
+				assert.Greater(t, idx, last, path)
+				last = idx
+			}
+
+			assert.Equal(t, "ok", f.Items[1].Cmd)
+		})
+	}
+
+	t.Run("Is And As", func(t *testing.T) {
+		errFoo := errors.New("foo")
+		l := errorList{
+			errors.New("bar"),
+			fmt.Errorf("wrapped: %w", &Error{Path: "a"}),
+			fmt.Errorf("wrapped: %w", errFoo),
+		}
+
+		// call methods directly, errors.Is and errors.As follow Unwrap() []error since go1.20
+		var rsErr *Error
+		if assert.True(t, l.As(&rsErr)) {
+			assert.Equal(t, "a", rsErr.Path)
+		}
+
+		var pathErr *fs.PathError
+		assert.False(t, l.As(&pathErr))
+
+		assert.True(t, l.Is(errFoo))
+		assert.False(t, l.Is(errors.New("foo")))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		f := Init(&Foo{}, nil).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(`items: [{cmd@err: x}, {cmd@err: y}]`), f))
+
+		err := f.ResolveFields(testRenderingHandler{}, -1)
+		assert.Error(t, err)
+		assert.Equal(t, 1, strings.Count(err.Error(), "always error"))
+	})
+}
@@ -3,6 +3,8 @@ package rs
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
@@ -29,11 +31,28 @@ type BaseField struct {
 	normalFields map[string]fieldRef
 	inlineMap    *fieldRef
 
+	// fieldKeys are yaml keys of normalFields in struct field declaration order
+	// (fields of inline structs are expanded in place)
+	fieldKeys []string
+	// inlineMapIndex is the index in fieldKeys where inlineMap was declared
+	inlineMapIndex int
+
 	// key: yamlKey
 	unresolvedNormalFields   map[string]unresolvedFieldSpec
 	unresolvedInlineMapItems map[string][]unresolvedFieldSpec
 
+	// unresolvedMapKeyItems are items of map fields with `rs:"keys"` tag
+	// having rendering suffix in their keys
+	//
+	// key: yamlKey of the map field -> map key
+	unresolvedMapKeyItems map[string]map[string][]unresolvedFieldSpec
+
 	unresolvedSelfItems []unresolvedFieldSpec
+
+	// sourceNode is the mapping node last unmarshaled, used by MarshalNode
+	//
+	// only set when Options.KeepSourceNode is enabled
+	sourceNode *yaml.Node
 }
 
 func (f *BaseField) initialized() bool {
@@ -44,10 +63,11 @@ type tagSpec struct {
 	// yamlKey is empty when field is unexported or ignored
 	yamlKey string
 
-	inline    bool
-	omitempty bool
-	inlineMap bool
-	disableRS bool
+	inline     bool
+	omitempty  bool
+	inlineMap  bool
+	disableRS  bool
+	renderKeys bool
 }
 
 // parseFieldTags
@@ -110,6 +130,18 @@ func (f *BaseField) parseFieldTags(sf *reflect.StructField, dataTagNS string) (r
 		case "":
 		case "disabled":
 			ret.disableRS = true
+		case "keys":
+			// rendering suffix in keys of built-in map
+			if sf.Type.Kind() != reflect.Map || sf.Type.Key().Kind() != reflect.String {
+				err = fmt.Errorf(
+					"rs tag keys not applicable to %s.%s: "+
+						"only map with string key is supported",
+					f._parentValue.Type().String(), sf.Name,
+				)
+				return
+			}
+
+			ret.renderKeys = true
 		default:
 			err = fmt.Errorf(
 				"unknown rs tag value %q for %s.%s",
@@ -119,6 +151,14 @@ func (f *BaseField) parseFieldTags(sf *reflect.StructField, dataTagNS string) (r
 		}
 	}
 
+	if ret.renderKeys && ret.inlineMap {
+		err = fmt.Errorf(
+			"rs tag keys not applicable to inline map %s.%s",
+			f._parentValue.Type().String(), sf.Name,
+		)
+		return
+	}
+
 	return ret, nil
 }
 
@@ -294,12 +334,46 @@ type fieldRef struct {
 
 	// disable rendering suffix support
 	disableRS bool
+
+	// renderKeys is set for map type field with `rs:"keys"` tag, rendering suffix
+	// in keys of the map are recognized
+	renderKeys bool
 }
 
 func (f *fieldRef) Elem() fieldRef {
 	return f.clone(f.fieldValue.Elem())
 }
 
+// parentType returns type of the struct this field belongs to, nil if unknown
+func (f *fieldRef) parentType() reflect.Type {
+	if f.base == nil || !f.base._parentValue.IsValid() {
+		return nil
+	}
+
+	return f.base._parentValue.Type()
+}
+
+// omitted returns true when the field has `omitempty` tag and its value is empty
+func (f *fieldRef) omitted() bool {
+	if !f.omitempty {
+		return false
+	}
+
+	vk := f.fieldValue.Kind()
+	if !f.fieldValue.IsValid() || (vk != reflect.Array && f.fieldValue.IsZero()) {
+		// value not set or zero value
+		return true
+	}
+
+	// value already set and not zero value
+	switch vk {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
+		return f.fieldValue.Len() == 0
+	}
+
+	return false
+}
+
 func (f *fieldRef) clone(v reflect.Value) (ret fieldRef) {
 	ret = *f
 	ret.fieldValue = v
@@ -333,6 +407,7 @@ func (f *BaseField) addField(
 			isInlineMap: true,
 			disableRS:   ts.disableRS,
 		}
+		f.inlineMapIndex = len(f.fieldKeys)
 
 		return true
 	}
@@ -357,27 +432,176 @@ func (f *BaseField) addField(
 		omitempty:   ts.omitempty,
 		isInlineMap: false,
 		disableRS:   ts.disableRS,
+		renderKeys:  ts.renderKeys,
 	}
+	f.fieldKeys = append(f.fieldKeys, ts.yamlKey)
 
 	return true
 }
 
+// yamlKeys returns yaml keys of all fields in struct field declaration order, keys of
+// the inline map (including unresolved inline map items) are sorted and placed where
+// the inline map field is declared, except those overridden by normal fields
+func (f *BaseField) yamlKeys() []string {
+	if f.inlineMap == nil {
+		return f.fieldKeys
+	}
+
+	var inlineKeys []string
+	if fv := f.inlineMap.fieldValue; fv.IsValid() {
+		for _, k := range fv.MapKeys() {
+			inlineKeys = append(inlineKeys, k.String())
+		}
+	}
+
+	for k := range f.unresolvedInlineMapItems {
+		inlineKeys = append(inlineKeys, k)
+	}
+
+	sort.Strings(inlineKeys)
+
+	ret := make([]string, 0, len(f.fieldKeys)+len(inlineKeys))
+	ret = append(ret, f.fieldKeys[:f.inlineMapIndex]...)
+	for i, k := range inlineKeys {
+		if i != 0 && inlineKeys[i-1] == k {
+			continue
+		}
+
+		if _, ok := f.normalFields[k]; ok {
+			continue
+		}
+
+		ret = append(ret, k)
+	}
+
+	return append(ret, f.fieldKeys[f.inlineMapIndex:]...)
+}
+
 func (f *BaseField) getField(yamlKey string) (ret fieldRef, ok bool) {
 	ret, ok = f.normalFields[yamlKey]
 	return
 }
 
+// UnresolvedField is a yaml key with rendering suffix waiting to be resolved
+type UnresolvedField struct {
+	// YamlKey is the yaml key without rendering suffix, it is `__` for virtual key
+	YamlKey string
+
+	// FieldName is the struct field name, empty for virtual key and inline map items
+	FieldName string
+
+	// MapKey is the key in the map field with `rs:"keys"` tag, empty for other fields
+	MapKey string
+
+	// Suffix is the parsed rendering suffix
+	Suffix RenderingSuffix
+
+	// Key is the yaml key node in the source document, can be nil when unknown
+	Key *yaml.Node
+
+	// Value is the raw yaml value to be rendered
+	Value *yaml.Node
+}
+
+// UnresolvedFields returns all yaml keys with rendering suffix not resolved yet, in the order of
+// virtual key items, fields in struct field declaration order and inline map items sorted by key
+//
+// NOTE: returned yaml nodes are shared with the BaseField, DO NOT modify them
+func (f *BaseField) UnresolvedFields() (ret []UnresolvedField) {
+	for i := range f.unresolvedSelfItems {
+		v := &f.unresolvedSelfItems[i]
+		ret = append(ret, UnresolvedField{
+			YamlKey: "__",
+			Suffix:  v.renderers,
+			Key:     v.key,
+			Value:   v.rawData,
+		})
+	}
+
+	for _, k := range f.fieldKeys {
+		ref := f.normalFields[k]
+		if v, ok := ref.base.unresolvedNormalFields[k]; ok {
+			ret = append(ret, UnresolvedField{
+				YamlKey:   k,
+				FieldName: ref.fieldName,
+				Suffix:    v.renderers,
+				Key:       v.key,
+				Value:     v.rawData,
+			})
+		}
+
+		items := ref.base.unresolvedMapKeyItems[k]
+		for _, mapKey := range sortedKeys(items) {
+			for _, v := range items[mapKey] {
+				ret = append(ret, UnresolvedField{
+					YamlKey:   k,
+					FieldName: ref.fieldName,
+					MapKey:    mapKey,
+					Suffix:    v.renderers,
+					Key:       v.key,
+					// raw data of map item is a fake map of the key and value
+					Value: v.rawData.Content[1],
+				})
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(f.unresolvedInlineMapItems) {
+		for _, v := range f.unresolvedInlineMapItems[k] {
+			ret = append(ret, UnresolvedField{
+				YamlKey: k,
+				Suffix:  v.renderers,
+				Key:     v.key,
+				// raw data of inline map item is a fake map of the key and value
+				Value: v.rawData.Content[1],
+			})
+		}
+	}
+
+	return
+}
+
+func sortedKeys(m map[string][]unresolvedFieldSpec) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
 type unresolvedFieldSpec struct {
 	// fieldName is struct field name when isInlineMapItem = false
 	// otherwise it's inline map item key
 	ref *fieldRef
 
 	rawData   *yaml.Node
-	renderers []rendererSpec
+	renderers []RendererSpec
+
+	// key is the yaml key node of the field, used for error reporting
+	//
+	// can be nil when the field was not unmarshaled from yaml
+	key *yaml.Node
+}
+
+// newError creates an *Error located at the yaml key of this field
+func (v *unresolvedFieldSpec) newError(path, renderer string, err error) error {
+	key := v.key
+	if key == nil {
+		key = v.rawData
+	}
+
+	return newError(v.ref.parentType(), path, key, renderer, err)
 }
 
 // nolint:revive
-func (f *BaseField) addUnresolvedField_self(suffix string, n *yaml.Node) error {
+func (f *BaseField) addUnresolvedField_self(suffix string, key, n *yaml.Node) error {
+	renderers, err := parseRenderingSuffix(suffix)
+	if err != nil {
+		return newError(f._parentValue.Type(), "", key, "", err)
+	}
+
 	f.unresolvedSelfItems = append(f.unresolvedSelfItems, unresolvedFieldSpec{
 		ref: &fieldRef{
 			tagName:     "",
@@ -390,7 +614,8 @@ func (f *BaseField) addUnresolvedField_self(suffix string, n *yaml.Node) error {
 		},
 
 		rawData:   n,
-		renderers: parseRenderingSuffix(suffix),
+		renderers: renderers,
+		key:       key,
 	})
 
 	return nil
@@ -401,28 +626,26 @@ func (f *BaseField) addUnresolvedField(
 	ref *fieldRef,
 	rawData *yaml.Node,
 	// key part
+	key *yaml.Node,
 	yamlKey string,
 	suffix string,
-	resolvedSuffix []rendererSpec,
+	resolvedSuffix []RendererSpec,
 ) error {
 	if resolvedSuffix == nil {
-		resolvedSuffix = parseRenderingSuffix(suffix)
+		var err error
+		resolvedSuffix, err = parseRenderingSuffix(suffix)
+		if err != nil {
+			return newError(f._parentValue.Type(), errorPathKey(yamlKey), key, "", err)
+		}
 	}
 
-	if f._opts != nil && f._opts.AllowedRenderers != nil {
-		n := len(resolvedSuffix)
-		var name string
-		for i := 0; i < n; i++ {
-			name = resolvedSuffix[i].name
-			_, ok := f._opts.AllowedRenderers[name]
-			if !ok {
-				return fmt.Errorf("renderer %q is not allowed", name)
-			}
-		}
+	err := f.checkAllowedRenderers(key, errorPathKey(yamlKey), resolvedSuffix)
+	if err != nil {
+		return err
 	}
 
 	if !ref.isInlineMap {
-		f.addUnresolvedNormalField(yamlKey, resolvedSuffix, ref, rawData)
+		f.addUnresolvedNormalField(key, yamlKey, resolvedSuffix, ref, rawData)
 		return nil
 	}
 
@@ -435,15 +658,134 @@ func (f *BaseField) addUnresolvedField(
 
 		rawData:   rawData,
 		renderers: resolvedSuffix,
+		key:       key,
 	})
 
 	return nil
 }
 
+// checkAllowedRenderers checks renderers against Options.AllowedRenderers
+func (f *BaseField) checkAllowedRenderers(key *yaml.Node, path string, renderers []RendererSpec) error {
+	if f._opts == nil || f._opts.AllowedRenderers == nil {
+		return nil
+	}
+
+	for i := range renderers {
+		if len(renderers[i].selectQuery) != 0 {
+			// inline select step is not a renderer
+			continue
+		}
+
+		name := renderers[i].name
+		_, ok := f._opts.AllowedRenderers[name]
+		if !ok {
+			return newError(
+				f._parentValue.Type(), path, key, name,
+				fmt.Errorf("renderer %q is not allowed", name),
+			)
+		}
+	}
+
+	return nil
+}
+
+// addUnresolvedMapKeyItems adds items of the map field with `rs:"keys"` tag having
+// rendering suffix in their keys as unresolved items, it returns n with those
+// items removed
+//
+// ref is the map field, yamlKey is the yaml key of the map field
+func (f *BaseField) addUnresolvedMapKeyItems(
+	ref *fieldRef,
+	yamlKey string,
+	n *yaml.Node,
+) (*yaml.Node, error) {
+	// drop items added by previous unmarshaling
+	delete(f.unresolvedMapKeyItems, yamlKey)
+
+	v := prepareYamlNode(n)
+	if v == nil || v.Kind != yaml.MappingNode {
+		return n, nil
+	}
+
+	pairs, err := unmarshalYamlMap(v.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		// items are resolved in the same way as inline map items
+		itemRef = ref.clone(ref.fieldValue)
+		items   map[string][]unresolvedFieldSpec
+
+		ret = *v
+	)
+
+	itemRef.isInlineMap = true
+	ret.Content = nil
+
+	for _, kv := range pairs {
+		rawKey := kv[0].Value
+		suffixStart := renderingSuffixStart(rawKey)
+		if suffixStart == -1 {
+			ret.Content = append(ret.Content, kv[0], kv[1])
+			continue
+		}
+
+		k := rawKey[:suffixStart]
+		path := joinErrorPath(errorPathKey(yamlKey), errorPathKey(k))
+
+		var renderers []RendererSpec
+		renderers, err = parseRenderingSuffix(rawKey[suffixStart+1:])
+		if err != nil {
+			return nil, newError(f._parentValue.Type(), path, kv[0], "", err)
+		}
+
+		err = f.checkAllowedRenderers(kv[0], path, renderers)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			fm        yaml.Node
+			clonedKey yaml.Node
+		)
+
+		value := prepareYamlNode(kv[1])
+		if value == nil {
+			value = kv[1]
+		}
+
+		cloneYamlNode(&clonedKey, kv[0], strTag, k)
+		fakeMap(&fm, &clonedKey, value)
+
+		if items == nil {
+			items = make(map[string][]unresolvedFieldSpec)
+		}
+
+		items[k] = append(items[k], unresolvedFieldSpec{
+			ref:       &itemRef,
+			rawData:   &fm,
+			renderers: renderers,
+			key:       kv[0],
+		})
+	}
+
+	if items != nil {
+		if f.unresolvedMapKeyItems == nil {
+			f.unresolvedMapKeyItems = make(map[string]map[string][]unresolvedFieldSpec)
+		}
+
+		f.unresolvedMapKeyItems[yamlKey] = items
+	}
+
+	return &ret, nil
+}
+
 func (f *BaseField) addUnresolvedNormalField(
 	// key part
+	key *yaml.Node,
 	yamlKey string,
-	renderers []rendererSpec,
+	renderers []RendererSpec,
 
 	// value part
 	ref *fieldRef,
@@ -456,6 +798,7 @@ func (f *BaseField) addUnresolvedNormalField(
 	// it can have multiple values only when it's an inline map item
 	if old, exists := f.unresolvedNormalFields[yamlKey]; exists {
 		old.rawData = rawData
+		old.key = key
 		f.unresolvedNormalFields[yamlKey] = old
 		return
 	}
@@ -464,17 +807,308 @@ func (f *BaseField) addUnresolvedNormalField(
 		ref:       ref,
 		rawData:   rawData,
 		renderers: renderers,
+		key:       key,
 	}
 }
 
-type rendererSpec struct {
+// RendererSpec is one renderer in the rendering suffix
+type RendererSpec struct {
 	name string
+	args RendererArgs
+
+	// selectQuery is the jq query of inline select step `jq(...)`
+	selectQuery string
 
 	patchSpec bool
 	typeHint  TypeHint
 }
 
-func parseRenderingSuffix(rs string) (ret []rendererSpec) {
+// Name of the renderer, empty for the pseudo built-in renderer
+func (s RendererSpec) Name() string { return s.name }
+
+// Args of the renderer in parentheses after its name, nil when not set
+func (s RendererSpec) Args() RendererArgs { return s.args }
+
+// Select is the jq query of the inline select step (e.g. `jq(.items[0])`), empty for renderers
+func (s RendererSpec) Select() string { return s.selectQuery }
+
+// TypeHint of the renderer output, nil when not set
+func (s RendererSpec) TypeHint() TypeHint { return s.typeHint }
+
+// Patch is true when the input is a patch spec (with `!` suffix)
+func (s RendererSpec) Patch() bool { return s.patchSpec }
+
+// String formats the renderer spec in rendering suffix style (e.g. `http(cache)?[]obj!`)
+func (s RendererSpec) String() string {
+	ret := s.name
+	switch {
+	case len(s.selectQuery) != 0:
+		ret += "(" + s.selectQuery + ")"
+	case s.args != nil:
+		ret += "(" + s.args.String() + ")"
+	}
+
+	if s.typeHint != nil {
+		ret += "?" + s.typeHint.String()
+	}
+
+	if s.patchSpec {
+		ret += "!"
+	}
+
+	return ret
+}
+
+// RendererArg is one renderer argument, Value is empty for flags (e.g. `cache`)
+//
+// values containing `,`, `(`, `)` or `"` can be double quoted (e.g. `q="a,b"`), they are
+// unquoted as go string literals
+type RendererArg struct {
+	Key   string
+	Value string
+}
+
+// RendererArgs are renderer arguments in rendering suffix (e.g. `http(timeout=5s,cache)`),
+// kept in the original order
+type RendererArgs []RendererArg
+
+// Get returns the value of the first argument named key, ok is false when not set
+func (a RendererArgs) Get(key string) (value string, ok bool) {
+	for _, arg := range a {
+		if arg.Key == key {
+			return arg.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// String formats renderer arguments without parentheses (e.g. `timeout=5s,cache`)
+func (a RendererArgs) String() string {
+	parts := make([]string, len(a))
+	for i, arg := range a {
+		switch {
+		case len(arg.Value) == 0:
+			parts[i] = arg.Key
+		case strings.ContainsAny(arg.Value, `,()"`) || strings.TrimSpace(arg.Value) != arg.Value:
+			parts[i] = arg.Key + "=" + strconv.Quote(arg.Value)
+		default:
+			parts[i] = arg.Key + "=" + arg.Value
+		}
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// RenderingSuffix is the parsed rendering suffix, renderers are applied in order
+type RenderingSuffix []RendererSpec
+
+// String formats the rendering suffix, it's the reverse of ParseRenderingSuffix
+func (s RenderingSuffix) String() string { return formatRenderingSuffix(s) }
+
+// yamlKeyWithSuffix formats yaml key with rendering suffix, rendering suffix is omitted
+// when there is no renderer (postponed by virtual key)
+func yamlKeyWithSuffix(yamlKey string, specs []RendererSpec) string {
+	if len(specs) == 0 {
+		return yamlKey
+	}
+
+	return yamlKey + "@" + formatRenderingSuffix(specs)
+}
+
+// formatRenderingSuffix is the reverse of parseRenderingSuffix
+func formatRenderingSuffix(specs []RendererSpec) string {
+	parts := make([]string, len(specs))
+	for i := range specs {
+		parts[i] = specs[i].String()
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// ParseRenderingSuffix parses rendering suffix (the part after `@` in yaml key, e.g. `env|http(cache)?[]obj!`),
+// an error is returned when the rendering suffix is invalid (e.g. unknown type hint)
+func ParseRenderingSuffix(suffix string) (RenderingSuffix, error) {
+	return parseRenderingSuffix(suffix)
+}
+
+// renderingSuffixStart returns the index of `@` starting the rendering suffix in rawKey,
+// `@` in parentheses is ignored, -1 when there is no rendering suffix
+//
+// when `(` is never closed and there is no `@` outside parentheses, the parentheses are
+// part of the key (e.g. `a(b@echo`), the last `@` starts the rendering suffix
+func renderingSuffixStart(rawKey string) int {
+	ret := -1
+	balanced := scanOutsideParens(rawKey, func(i int) bool {
+		if rawKey[i] == '@' {
+			ret = i
+		}
+
+		return true
+	})
+
+	if ret == -1 && !balanced {
+		return strings.LastIndexByte(rawKey, '@')
+	}
+
+	return ret
+}
+
+// indexRendererSep returns the index of the first `|` not in parentheses, -1 when not found
+func indexRendererSep(s string) int {
+	ret := -1
+	scanOutsideParens(s, func(i int) bool {
+		if s[i] == '|' {
+			ret = i
+			return false
+		}
+
+		return true
+	})
+
+	return ret
+}
+
+// closingParen returns the index of `)` closing `(` at s[start], -1 when not closed
+func closingParen(s string, start int) int {
+	depth, quoted := 0, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted:
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// scanOutsideParens calls fn with index of every byte in s not in parentheses until fn returns false,
+// double quoted strings in parentheses (e.g. in jq query) are skipped as a whole
+//
+// balanced is false when s ends in parentheses, only meaningful when fn never returns false
+func scanOutsideParens(s string, fn func(i int) bool) (balanced bool) {
+	depth, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted:
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+		case depth > 0 && c == '"':
+			quoted = true
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			if !fn(i) {
+				return
+			}
+		}
+	}
+
+	return depth == 0
+}
+
+// parseRendererArgs parses renderer arguments without parentheses
+func parseRendererArgs(s string) (RendererArgs, error) {
+	// `foo()` has empty but not nil args
+	ret := RendererArgs{}
+	if len(strings.TrimSpace(s)) == 0 {
+		return ret, nil
+	}
+
+	parts, err := splitRendererArgs(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts {
+		var arg RendererArg
+		if idx := strings.IndexByte(part, '='); idx >= 0 {
+			arg.Key, arg.Value = strings.TrimSpace(part[:idx]), strings.TrimSpace(part[idx+1:])
+
+			if len(arg.Value) != 0 && arg.Value[0] == '"' {
+				v, err := strconv.Unquote(arg.Value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid quoted value of renderer argument %q: %w", arg.Key, err)
+				}
+
+				arg.Value = v
+			}
+		} else {
+			arg.Key = strings.TrimSpace(part)
+		}
+
+		if len(arg.Key) == 0 {
+			return nil, fmt.Errorf("empty renderer argument name in %q", s)
+		}
+
+		if _, ok := ret.Get(arg.Key); ok {
+			return nil, fmt.Errorf("duplicate renderer argument %q", arg.Key)
+		}
+
+		ret = append(ret, arg)
+	}
+
+	return ret, nil
+}
+
+// splitRendererArgs splits renderer arguments by `,` not in double quoted strings
+func splitRendererArgs(s string) ([]string, error) {
+	var (
+		ret    []string
+		start  int
+		quoted bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted:
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '(':
+			return nil, fmt.Errorf("nested parentheses in renderer arguments")
+		case c == ',':
+			ret = append(ret, s[start:i])
+			start = i + 1
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unclosed double quoted string in renderer arguments")
+	}
+
+	return append(ret, s[start:]), nil
+}
+
+func parseRenderingSuffix(rs string) (ret []RendererSpec, err error) {
 	var (
 		i      int
 		idx    int
@@ -484,7 +1118,7 @@ func parseRenderingSuffix(rs string) (ret []rendererSpec) {
 	)
 
 	for i = 0; i < sz; i += idx + 1 {
-		idx = strings.IndexByte(rs[i:], '|')
+		idx = indexRendererSep(rs[i:])
 		if idx == -1 {
 			part = rs[i:]
 			szPart = sz - i
@@ -498,7 +1132,7 @@ func parseRenderingSuffix(rs string) (ret []rendererSpec) {
 			continue
 		}
 
-		spec := rendererSpec{
+		spec := RendererSpec{
 			patchSpec: part[szPart-1] == '!',
 		}
 
@@ -507,21 +1141,54 @@ func parseRenderingSuffix(rs string) (ret []rendererSpec) {
 			// size-- // size not used any more
 		}
 
+		var name string
+		if start := strings.IndexByte(part, '('); start >= 0 {
+			end := closingParen(part, start)
+			switch {
+			case end == -1:
+				return nil, fmt.Errorf("invalid rendering suffix %q: unclosed renderer arguments", rs)
+			case end+1 < len(part) && part[end+1] != '?':
+				return nil, fmt.Errorf("invalid rendering suffix %q: unexpected %q after renderer arguments", rs, part[end+1:])
+			}
+
+			name = part[:start]
+			if name == "jq" {
+				// inline select step, the whole content in parentheses is the jq query
+				spec.selectQuery = strings.TrimSpace(part[start+1 : end])
+				if len(spec.selectQuery) == 0 {
+					return nil, fmt.Errorf("invalid rendering suffix %q: empty jq query", rs)
+				}
+			} else {
+				spec.args, err = parseRendererArgs(part[start+1 : end])
+				if err != nil {
+					return nil, fmt.Errorf("invalid rendering suffix %q: %w", rs, err)
+				}
+			}
+
+			part = part[end+1:]
+		}
+
 		if idx := strings.LastIndexByte(part, '?'); idx >= 0 {
-			// TODO: do we really want to panic when type hint is not valid?
-			var err error
 			spec.typeHint, err = ParseTypeHint(part[idx+1:])
 			if err != nil {
-				panic(err)
+				return nil, fmt.Errorf("invalid rendering suffix %q: %w", rs, err)
 			}
 
 			part = part[:idx]
 		}
 
-		spec.name = part
+		if spec.args != nil || len(spec.selectQuery) != 0 {
+			spec.name = name
+		} else {
+			spec.name = part
+		}
+
+		if spec.name == "jq" && len(spec.selectQuery) == 0 {
+			return nil, fmt.Errorf("invalid rendering suffix %q: renderer name `jq` is reserved for inline select step `jq(<query>)`", rs)
+		}
 
 		ret = append(ret, spec)
 	}
 
-	return ret
+	return ret, nil
 }
@@ -1,7 +1,9 @@
 package rs
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,19 +52,247 @@ func (testRenderingHandler) RenderYaml(renderer string, data any) (result []byte
 func TestParseRenderingSuffix(t *testing.T) {
 	for _, test := range []struct {
 		suffix   string
-		expected []rendererSpec
+		expected RenderingSuffix
 	}{
 		{"", nil},
 		{"|||||", nil},
-		{"foo", []rendererSpec{{name: "foo"}}},
-		{"foo!", []rendererSpec{{name: "foo", patchSpec: true}}},
-		{"foo?int", []rendererSpec{{name: "foo", typeHint: TypeHintInt{}}}},
-		{"foo?str!", []rendererSpec{{name: "foo", patchSpec: true, typeHint: TypeHintStr{}}}},
-		{"foo|bar", []rendererSpec{{name: "foo"}, {name: "bar"}}},
+		{"foo", []RendererSpec{{name: "foo"}}},
+		{"foo!", []RendererSpec{{name: "foo", patchSpec: true}}},
+		{"foo?int", []RendererSpec{{name: "foo", typeHint: TypeHintInt{}}}},
+		{"foo?str!", []RendererSpec{{name: "foo", patchSpec: true, typeHint: TypeHintStr{}}}},
+		{"foo|bar", []RendererSpec{{name: "foo"}, {name: "bar"}}},
+		{"foo()", []RendererSpec{{name: "foo", args: RendererArgs{}}}},
+		{"http(timeout=5s,cache)|tmpl", []RendererSpec{
+			{name: "http", args: RendererArgs{{Key: "timeout", Value: "5s"}, {Key: "cache"}}},
+			{name: "tmpl"},
+		}},
+		{"http(url=a|b?c=d@e, x = y)?[]obj!", []RendererSpec{{
+			name:      "http",
+			args:      RendererArgs{{Key: "url", Value: "a|b?c=d@e"}, {Key: "x", Value: "y"}},
+			patchSpec: true,
+			typeHint:  TypeHintObjects{},
+		}}},
+		{`http(q="a,b", p = "f(x)|y", e="\"")`, []RendererSpec{{
+			name: "http",
+			args: RendererArgs{{Key: "q", Value: "a,b"}, {Key: "p", Value: "f(x)|y"}, {Key: "e", Value: `"`}},
+		}}},
+		{`http?obj|jq(.items | map(select(.name != "a)|@")) | first)?str`, []RendererSpec{
+			{name: "http", typeHint: TypeHintObject{}},
+			{name: "jq", selectQuery: `.items | map(select(.name != "a)|@")) | first`, typeHint: TypeHintStr{}},
+		}},
 	} {
 		t.Run(test.suffix, func(t *testing.T) {
-			ret := parseRenderingSuffix(test.suffix)
+			ret, err := ParseRenderingSuffix(test.suffix)
+			assert.NoError(t, err)
 			assert.EqualValues(t, test.expected, ret)
 		})
 	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := ParseRenderingSuffix("foo|bar?intt")
+		assert.ErrorContains(t, err, `unknown type hint "intt"`)
+
+		for suffix, msg := range map[string]string{
+			"foo(a":       "unclosed renderer arguments",
+			"foo(a(b))":   "nested parentheses",
+			"foo(a)bar":   `unexpected "bar" after renderer arguments`,
+			"foo(a,,b)":   "empty renderer argument name",
+			"foo(=b)":     "empty renderer argument name",
+			"foo(a,b,a)":  `duplicate renderer argument "a"`,
+			`foo(a="b)`:   "unclosed renderer arguments",
+			`foo(a="b\")`: "unclosed",
+			`foo(a="b"c)`: "invalid quoted value",
+			"jq( )":       "empty jq query",
+			"jq(.a":       "unclosed renderer arguments",
+			"jq":          "renderer name `jq` is reserved",
+			"foo|jq?str!": "renderer name `jq` is reserved",
+		} {
+			_, err = ParseRenderingSuffix(suffix)
+			assert.ErrorContains(t, err, msg, suffix)
+		}
+	})
+}
+
+func TestRenderingSuffixStart(t *testing.T) {
+	for rawKey, expected := range map[string]int{
+		"foo":                  -1,
+		"foo@echo":             3,
+		"a@b@echo":             3,
+		"foo@http(url=a@b)":    3,
+		"foo@jq(\"@\")":        3,
+		"a(b)@echo":            4,
+		"a(b@c)":               -1,
+		"a(b@echo":             3,
+		"a(b@c@echo":           5,
+		"foo@http(url=a@b":     3,
+		"a(b)c(d@echo":         7,
+		"foo@http(q=\"a)@b\")": 3,
+	} {
+		assert.Equal(t, expected, renderingSuffixStart(rawKey), rawKey)
+	}
+}
+
+func TestRendererSpec_String(t *testing.T) {
+	for _, suffix := range []string{
+		"foo",
+		"foo()?int",
+		"http(timeout=5s,cache)|tmpl!",
+		`http(q="a,b",p=" x")`,
+		"http|jq(.items[0] | .name)?str",
+	} {
+		specs, err := ParseRenderingSuffix(suffix)
+		assert.NoError(t, err)
+		assert.Equal(t, suffix, specs.String())
+	}
+}
+
+func TestBaseField_ResolveFields_rendererArgs(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Foo   string            `yaml:"foo"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	rc := ArgsRenderingHandleFunc(func(
+		ctx context.Context, renderer string, args RendererArgs, rawData any,
+	) ([]byte, error) {
+		return []byte(renderer + "(" + args.String() + ")"), nil
+	})
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+foo@http(timeout=5s,cache)|tmpl: x
+bar@http(url=http://a@b/c): y
+`), f))
+	assert.NoError(t, f.ResolveFields(rc, -1))
+	assert.Equal(t, "tmpl()", f.Foo)
+	assert.Equal(t, map[string]string{"bar": "http(url=http://a@b/c)"}, f.Other)
+
+	f = Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`foo@echo(a): x`), f))
+	assert.ErrorContains(t, f.ResolveFields(testRenderingHandler{}, -1), "renderer arguments not supported")
+
+	// unclosed parentheses in the key
+	f = Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`a(b@echo: x`), f))
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+	assert.Equal(t, map[string]string{"a(b": "x"}, f.Other)
+
+	// unclosed parentheses in the rendering suffix
+	f = Init(&Foo{}, nil).(*Foo)
+	assert.ErrorContains(t, yaml.Unmarshal([]byte(`foo@http(url=a@b: x`), f), "unclosed renderer arguments")
+}
+
+func TestBaseField_UnmarshalYAML_invalidRenderingSuffix(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Foo   int            `yaml:"foo"`
+		Items []AnyObject    `yaml:"items"`
+		Other map[string]int `yaml:",inline"`
+	}
+
+	for _, test := range []struct {
+		input string
+		path  string
+	}{
+		{input: `foo@env?intt: 1`, path: "foo"},
+		{input: `foo: !rs:env?intt 1`, path: "foo"},
+		{input: `bar@env?intt: 1`, path: "bar"},
+		{input: `__@env?intt: {}`, path: ""},
+	} {
+		t.Run(test.input, func(t *testing.T) {
+			f := Init(&Foo{}, nil).(*Foo)
+
+			var err error
+			assert.NotPanics(t, func() {
+				err = yaml.Unmarshal([]byte(test.input), f)
+			})
+
+			var rsErr *Error
+			if assert.ErrorAs(t, err, &rsErr) {
+				assert.Equal(t, test.path, rsErr.Path)
+				assert.Equal(t, 1, rsErr.Line)
+			}
+			assert.ErrorContains(t, err, `unknown type hint "intt"`)
+		})
+	}
+
+	t.Run("Virtual Key in List", func(t *testing.T) {
+		f := Init(&Foo{}, nil).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte("items@echo:\n- __@echo?intt: {}"), f))
+
+		err := f.ResolveFields(testRenderingHandler{}, -1)
+		assert.ErrorContains(t, err, `unknown type hint "intt"`)
+	})
+}
+
+func TestRenderingSuffix_String(t *testing.T) {
+	for _, suffix := range []string{
+		"",
+		"foo",
+		"foo!",
+		"foo?int",
+		"?str!",
+		"foo|bar?[]obj!|",
+	} {
+		t.Run(suffix, func(t *testing.T) {
+			specs, err := ParseRenderingSuffix(suffix)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			formatted := specs.String()
+			assert.Equal(t, strings.TrimSuffix(suffix, "|"), formatted)
+
+			reparsed, err := ParseRenderingSuffix(formatted)
+			assert.NoError(t, err)
+			assert.EqualValues(t, specs, reparsed)
+		})
+	}
+
+	specs, err := ParseRenderingSuffix("http?[]obj!")
+	if assert.NoError(t, err) && assert.Len(t, specs, 1) {
+		assert.Equal(t, "http", specs[0].Name())
+		assert.Equal(t, TypeHintObjects{}, specs[0].TypeHint())
+		assert.True(t, specs[0].Patch())
+	}
+}
+
+func TestBaseField_UnresolvedFields(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		B     string            `yaml:"b"`
+		A     string            `yaml:"a"`
+		Map   map[string]string `yaml:"map" rs:"keys"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+other@y: 1
+a@x|y?int: 2
+b: 3
+map:
+  k@x: 4
+other@x: 5
+__@z!: {}
+`), f))
+
+	var actual []string
+	for _, v := range f.UnresolvedFields() {
+		actual = append(actual, fmt.Sprintf("%s %s %s %s %s %d",
+			v.YamlKey, v.FieldName, v.MapKey, v.Suffix, v.Value.Value, v.Key.Line,
+		))
+	}
+
+	assert.Equal(t, []string{
+		"__   z!  8",
+		"a A  x|y?int 2 3",
+		"map Map k x 4 6",
+		"other   y 1 2",
+		"other   x 5 7",
+	}, actual)
 }
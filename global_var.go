@@ -11,6 +11,8 @@ var (
 	typePtr_BaseField    = reflect.TypeOf((*BaseField)(nil))
 	typeStruct_BaseField = typePtr_BaseField.Elem()
 
+	typeStruct_PatchSpec = reflect.TypeOf((*PatchSpec)(nil)).Elem()
+
 	typeEface_Field = reflect.TypeOf((*Field)(nil)).Elem()
 	typeEface_Any   = reflect.TypeOf((*any)(nil)).Elem()
 )
@@ -4,7 +4,6 @@ go 1.18
 
 require (
 	arhat.dev/pkg v0.10.0
-	github.com/evanphx/json-patch/v5 v5.6.0
 	github.com/itchyny/gojq v0.12.8
 	github.com/stretchr/testify v1.8.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -40,6 +40,7 @@ func (f *BaseField) Inherit(other *BaseField) error {
 				err := f.addUnresolvedField(
 					ref,
 					v.rawData,
+					v.key,
 					k,
 					"", v.renderers,
 				)
@@ -61,6 +62,7 @@ func (f *BaseField) Inherit(other *BaseField) error {
 			}
 
 			existingV.rawData = v.rawData
+			existingV.key = v.key
 			f.unresolvedNormalFields[k] = existingV
 		}
 	}
@@ -79,6 +81,7 @@ func (f *BaseField) Inherit(other *BaseField) error {
 					ref:       f.inlineMap,
 					rawData:   v.rawData,
 					renderers: v.renderers,
+					key:       v.key,
 				})
 			}
 		}
@@ -50,7 +50,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						fieldValue: v._parentValue.FieldByName("Data"),
 					},
 					fakeScalarNode("value-b"),
-					"data", "test", nil,
+					nil, "data", "test", nil,
 				)
 				return v
 			}(),
@@ -69,7 +69,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						fieldValue: v._parentValue.FieldByName("Data"),
 					},
 					fakeScalarNode("value-a"),
-					"data", "test", nil,
+					nil, "data", "test", nil,
 				)
 				return v
 			}(),
@@ -82,7 +82,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						fieldValue: v._parentValue.FieldByName("Data"),
 					},
 					fakeScalarNode("value-b"),
-					"data", "test", nil,
+					nil, "data", "test", nil,
 				)
 				return v
 			}(),
@@ -103,7 +103,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						isInlineMap: true,
 					},
 					fakeMapPtr(fakeScalarNode("data"), fakeScalarNode("test-data")),
-					"b", "test", nil,
+					nil, "b", "test", nil,
 				)
 
 				return v
@@ -124,7 +124,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						isInlineMap: true,
 					},
 					fakeMapPtr(fakeScalarNode("a"), fakeScalarNode("test-data")),
-					"a", "test", nil,
+					nil, "a", "test", nil,
 				)
 				return v
 			}(),
@@ -138,7 +138,7 @@ func TestBaseField_Inherit(t *testing.T) {
 						isInlineMap: true,
 					},
 					fakeMapPtr(fakeScalarNode("b"), fakeScalarNode("test-data")),
-					"b", "test", nil,
+					nil, "b", "test", nil,
 				)
 
 				return v
@@ -159,7 +159,7 @@ func TestBaseField_Inherit(t *testing.T) {
 					expectedUnresolvedFields[k] = unresolvedFieldSpec{
 						ref:       v.ref.clone(reflect.Value{}).stripBase(),
 						rawData:   v.rawData,
-						renderers: append([]rendererSpec{}, v.renderers...),
+						renderers: append([]RendererSpec{}, v.renderers...),
 					}
 				}
 			} else {
@@ -192,7 +192,7 @@ func TestBaseField_Inherit(t *testing.T) {
 					expectedUnresolvedFields[k] = unresolvedFieldSpec{
 						ref:       v.ref.clone(reflect.Value{}).stripBase(),
 						rawData:   v.rawData,
-						renderers: append([]rendererSpec{}, v.renderers...),
+						renderers: append([]RendererSpec{}, v.renderers...),
 					}
 				}
 			}
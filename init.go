@@ -4,6 +4,11 @@ import (
 	"reflect"
 )
 
+// Options for BaseField, set in Init and shared with nested structs initialized by it
+//
+// patch specs (`foo@!`) and values in them (e.g. `value`, `merge[*].value` and `patch[*].value`)
+// use the Options of the struct having the patched field, so settings like AllowedRenderers,
+// StrictTypeHint, CollectAllErrors, RenderingTracer and JQFunctions apply to them as well
 type Options struct {
 	// InterfaceTypeHandler handles interface value creation for any field
 	// with a interface{...} type (including []interface{...} and map[string]interface{...})
@@ -43,7 +48,70 @@ type Options struct {
 	// when set, only renderers with exact name matching will be allowed,
 	// thus you may need to set an empty entry to allow pseudo built-in
 	// empty renderer
+	//
+	// inline select step `jq(...)` is not a renderer and is always allowed
 	AllowedRenderers map[string]struct{}
+
+	// RenderingConcurrency is the max number of renderers running at the same time
+	// when resolving fields of the struct, rendered values are still applied to
+	// struct fields one by one in the original order
+	//
+	// when it's greater than 1, your RenderingHandler MUST be safe for concurrent use
+	//
+	// defaults to `0` (render one by one)
+	RenderingConcurrency int
+
+	// CollectAllErrors keeps unmarshaling and resolving after failures (e.g. unknown keys,
+	// disallowed renderers, renderer errors and type hint failures), then returns
+	// all of them as a single error, one line per failure with its key path
+	//
+	// defaults to `false` (return on the first failure)
+	CollectAllErrors bool
+
+	// RenderingTracer receives an event for every rendering step when resolving fields,
+	// use RenderingRecorder to record the provenance of resolved values
+	//
+	// defaults to `nil` (no tracing)
+	RenderingTracer RenderingTracer
+
+	// MarshalUnresolvedFields makes MarshalYAML emit fields with rendering suffix
+	// (including virtual key `__` and keys of map fields with `rs:"keys"` tag)
+	// using their original yaml keys and raw values
+	// instead of current field values, so the output can be unmarshaled again
+	// without any value rendered
+	//
+	// NOTE: fields with rendering suffix are always emitted as unresolved, even if they
+	// have been resolved or modified after unmarshaling
+	//
+	// defaults to `false`
+	MarshalUnresolvedFields bool
+
+	// KeepSourceNode keeps the yaml node of each struct when unmarshaling, so BaseField.MarshalNode
+	// can reuse it to keep key order, comments, anchors and scalar styles
+	//
+	// NOTE: the whole source document is kept in memory as long as the struct is alive
+	//
+	// defaults to `false` (MarshalNode makes new nodes as MarshalYAML does)
+	KeepSourceNode bool
+
+	// StrictTypeHint makes type hints validate values after rendering, lossy conversions
+	// are rejected with the renderer, type hint and the offending value reported
+	//
+	// - `?int`, `?float` and `?bool` require parsable values (e.g. `12abc` is not an int)
+	// - `?str` rejects objects and lists instead of converting them to yaml text
+	//
+	// defaults to `false`
+	StrictTypeHint bool
+
+	// JQFunctions are custom functions available in jq queries, including select of
+	// patch spec and inline select step `jq(...)`
+	//
+	// NOTE: compiled jq queries are cached by the backing array of this slice and names and
+	// arities of functions in it, so Options sharing the same slice share compiled queries,
+	// elements of the slice MUST NOT be modified after first use, set a new slice instead
+	//
+	// defaults to `nil` (only built-in jq functions)
+	JQFunctions []JQFunction
 }
 
 // Init the BaseField embedded in your struct, the BaseField must be the first field
@@ -0,0 +1,169 @@
+package rs
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/itchyny/gojq"
+)
+
+// DefaultJQCacheSize is the default max number of compiled jq queries cached
+const DefaultJQCacheSize = 256
+
+// SetJQCacheSize sets the max number of compiled jq queries cached, least recently used
+// queries are dropped when exceeded, set it to 0 to disable caching
+//
+// it's safe to call it at any time, cached queries are kept when still in the limit
+func SetJQCacheSize(size int) {
+	jqCache.resize(size)
+}
+
+var jqCache = newJQCodeCache(DefaultJQCacheSize)
+
+// jqCacheKey identifies a compiled jq query
+type jqCacheKey struct {
+	query string
+
+	// names of jq variables joined by `,`, in order
+	vars string
+
+	// func values are not comparable, so jq functions are identified by the backing
+	// array of Options.JQFunctions, along with their names and arities (`<name>/<min>/<max>`
+	// joined by `,`), Options sharing the same slice share compiled queries
+	funcs    *JQFunction
+	funcSigs string
+}
+
+func newJQCacheKey(query string, varNames []string, opts *Options) jqCacheKey {
+	key := jqCacheKey{
+		query: query,
+		vars:  strings.Join(varNames, ","),
+	}
+
+	if opts == nil || len(opts.JQFunctions) == 0 {
+		return key
+	}
+
+	sigs := make([]string, len(opts.JQFunctions))
+	for i, fn := range opts.JQFunctions {
+		sigs[i] = fmt.Sprintf("%s/%d/%d", fn.Name, fn.MinArity, fn.MaxArity)
+	}
+
+	key.funcs = &opts.JQFunctions[0]
+	key.funcSigs = strings.Join(sigs, ",")
+	return key
+}
+
+type jqCacheEntry struct {
+	key  jqCacheKey
+	code *gojq.Code
+}
+
+// jqCodeCache is a concurrency safe LRU cache of compiled jq queries
+type jqCodeCache struct {
+	mu sync.Mutex
+
+	size    int
+	entries map[jqCacheKey]*list.Element
+	// most recently used at front
+	lru list.List
+}
+
+func newJQCodeCache(size int) *jqCodeCache {
+	return &jqCodeCache{
+		size:    size,
+		entries: make(map[jqCacheKey]*list.Element),
+	}
+}
+
+func (c *jqCodeCache) get(key jqCacheKey) (*gojq.Code, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*jqCacheEntry).code, true
+}
+
+func (c *jqCodeCache) add(key jqCacheKey, code *gojq.Code) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		// compiled concurrently
+		elem.Value.(*jqCacheEntry).code = code
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&jqCacheEntry{key: key, code: code})
+	c.evict()
+}
+
+func (c *jqCodeCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size = size
+	c.evict()
+}
+
+// len returns the number of cached queries
+func (c *jqCodeCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// evict drops least recently used entries exceeding the size limit, c.mu MUST be held
+func (c *jqCodeCache) evict() {
+	for c.lru.Len() > 0 && c.lru.Len() > c.size {
+		elem := c.lru.Back()
+		c.lru.Remove(elem)
+		delete(c.entries, elem.Value.(*jqCacheEntry).key)
+	}
+}
+
+// compileJQ returns the compiled jq query from cache, or compiles and caches it,
+// with jq functions in opts (can be nil)
+func compileJQ(query string, varNames []string, opts *Options) (*gojq.Code, error) {
+	key := newJQCacheKey(query, varNames, opts)
+	if code, ok := jqCache.get(key); ok {
+		return code, nil
+	}
+
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq query: %w", err)
+	}
+
+	var funcs []JQFunction
+	if opts != nil {
+		funcs = opts.JQFunctions
+	}
+
+	compilerOpts := make([]gojq.CompilerOption, 0, len(funcs)+1)
+	compilerOpts = append(compilerOpts, gojq.WithVariables(varNames))
+	for _, fn := range funcs {
+		compilerOpts = append(compilerOpts, gojq.WithFunction(fn.Name, fn.MinArity, fn.MaxArity, fn.Fn))
+	}
+
+	code, err := gojq.Compile(q, compilerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("compile jq query: %w", err)
+	}
+
+	jqCache.add(key, code)
+	return code, nil
+}
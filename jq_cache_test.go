@@ -0,0 +1,114 @@
+package rs
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJQCodeCache(t *testing.T) {
+	c := newJQCodeCache(2)
+
+	keyA := newJQCacheKey(".a", nil, nil)
+	keyB := newJQCacheKey(".b", nil, nil)
+	keyC := newJQCacheKey(".c", nil, nil)
+
+	codeA, err := compileJQ(".a", nil, nil)
+	assert.NoError(t, err)
+
+	c.add(keyA, codeA)
+	c.add(keyB, codeA)
+
+	// keyA is now the most recently used
+	_, ok := c.get(keyA)
+	assert.True(t, ok)
+
+	c.add(keyC, codeA)
+	assert.Equal(t, 2, c.len())
+
+	_, ok = c.get(keyB)
+	assert.False(t, ok)
+	_, ok = c.get(keyA)
+	assert.True(t, ok)
+
+	c.resize(0)
+	assert.Equal(t, 0, c.len())
+	c.add(keyA, codeA)
+	assert.Equal(t, 0, c.len())
+}
+
+func TestJQCacheKey(t *testing.T) {
+	funcs := []JQFunction{{Name: "f", Fn: func(v any, _ []any) any { return v }}}
+	opts := &Options{JQFunctions: funcs}
+
+	// keys hold pointers, compare them with == as assert.Equal compares pointers deeply
+	assert.True(t, newJQCacheKey(".", []string{"$a"}, opts) == newJQCacheKey(".", []string{"$a"}, opts))
+	assert.True(t, newJQCacheKey(".", []string{"$a"}, opts) != newJQCacheKey(".", []string{"$b"}, opts))
+	assert.True(t, newJQCacheKey(".", nil, opts) != newJQCacheKey(".", nil, nil))
+	assert.True(t, newJQCacheKey(".", nil, &Options{}) == newJQCacheKey(".", nil, nil))
+
+	// Options sharing the same slice
+	assert.True(t, newJQCacheKey(".", nil, opts) == newJQCacheKey(".", nil, &Options{JQFunctions: funcs}))
+
+	// same functions in another slice
+	copied := append([]JQFunction{}, funcs...)
+	assert.True(t, newJQCacheKey(".", nil, opts) != newJQCacheKey(".", nil, &Options{JQFunctions: copied}))
+
+	// backing array reused for another function
+	key := newJQCacheKey(".", nil, opts)
+	funcs[0] = JQFunction{Name: "g", Fn: funcs[0].Fn}
+	assert.True(t, key != newJQCacheKey(".", nil, opts))
+}
+
+func TestRunJQ_jqFunctions(t *testing.T) {
+	funcs := []JQFunction{{Name: "f", Fn: func(v any, _ []any) any { return "a" }}}
+
+	t.Run("Reuse", func(t *testing.T) {
+		// Options built per call with the same slice share compiled queries
+		codeA, err := compileJQ("f", nil, &Options{JQFunctions: funcs})
+		assert.NoError(t, err)
+		codeB, err := compileJQ("f", nil, &Options{JQFunctions: funcs})
+		assert.NoError(t, err)
+		assert.Same(t, codeA, codeB)
+
+		ret, err := runJQ(context.TODO(), "f", nil, nil, &Options{JQFunctions: funcs})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", ret)
+	})
+
+	t.Run("Mutation", func(t *testing.T) {
+		// functions changed by a new slice
+		changed := []JQFunction{{Name: "f", Fn: func(v any, _ []any) any { return "b" }}}
+		ret, err := runJQ(context.TODO(), "f", nil, nil, &Options{JQFunctions: changed})
+		assert.NoError(t, err)
+		assert.Equal(t, "b", ret)
+
+		// elements MUST NOT be modified in place, queries compiled with the
+		// old function are still used
+		funcs[0].Fn = func(v any, _ []any) any { return "c" }
+		ret, err = runJQ(context.TODO(), "f", nil, nil, &Options{JQFunctions: funcs})
+		assert.NoError(t, err)
+		assert.Equal(t, "a", ret)
+	})
+}
+
+func TestRunJQ_concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				ret, err := runJQ(context.TODO(), ". + $n", 1, map[string]any{"n": j % 10}, nil)
+				assert.NoError(t, err, strconv.Itoa(i))
+				assert.Equal(t, 1+j%10, ret)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,403 @@
+package rs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Operations of rfc6902 json patch
+const (
+	JSONPatchOpAdd     = "add"
+	JSONPatchOpRemove  = "remove"
+	JSONPatchOpReplace = "replace"
+	JSONPatchOpMove    = "move"
+	JSONPatchOpCopy    = "copy"
+	JSONPatchOpTest    = "test"
+)
+
+// JSONPatchOperation is a single rfc6902 json patch operation with resolved value
+type JSONPatchOperation struct {
+	// Op is one of add, remove, replace, move, copy and test
+	Op string
+
+	// Path is the json pointer (rfc6901) to the target location
+	Path string
+
+	// From is the json pointer to the source location of move and copy
+	From string
+
+	// Value to add, replace or test
+	Value *yaml.Node
+}
+
+// ApplyJSONPatch applies rfc6902 json patch operations to doc in order, operating on
+// yaml nodes directly, so key order, scalar tags and comments of untouched data are kept
+//
+// negative list indices count from the end of the list (for add, -1 appends), removing
+// data at non-existing path is a no-op
+//
+// doc is modified in place (even if an operation failed), the returned node SHOULD be
+// used as the result since the root can be replaced
+func ApplyJSONPatch(doc *yaml.Node, ops []JSONPatchOperation) (_ *yaml.Node, err error) {
+	for i, op := range ops {
+		doc, err = applyJSONPatchOperation(doc, &op)
+		if err != nil {
+			return nil, fmt.Errorf("json patch #%d (%s %q): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func applyJSONPatchOperation(doc *yaml.Node, op *JSONPatchOperation) (*yaml.Node, error) {
+	path, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case JSONPatchOpAdd:
+		return jsonPatchAdd(doc, path, jsonPatchValue(op.Value))
+	case JSONPatchOpRemove:
+		if len(path) == 0 {
+			return nil, fmt.Errorf("cannot remove root")
+		}
+
+		jsonPatchRemove(doc, path)
+		return doc, nil
+	case JSONPatchOpReplace:
+		if len(path) == 0 {
+			return jsonPatchValue(op.Value), nil
+		}
+
+		parent, ok := jsonPointerGet(doc, path[:len(path)-1])
+		if !ok {
+			return nil, fmt.Errorf("path not found")
+		}
+
+		err = jsonPatchReplace(parent, path[len(path)-1], jsonPatchValue(op.Value))
+		return doc, err
+	case JSONPatchOpMove:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+
+		if op.From == op.Path {
+			return doc, nil
+		}
+
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %q into its child", op.From)
+		}
+
+		if len(from) == 0 {
+			return nil, fmt.Errorf("cannot move root")
+		}
+
+		v := jsonPatchRemove(doc, from)
+		if v == nil {
+			return nil, fmt.Errorf("from %q not found", op.From)
+		}
+
+		return jsonPatchAdd(doc, path, v)
+	case JSONPatchOpCopy:
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+
+		v, ok := jsonPointerGet(doc, from)
+		if !ok {
+			return nil, fmt.Errorf("from %q not found", op.From)
+		}
+
+		return jsonPatchAdd(doc, path, deepCopyYamlNode(v))
+	case JSONPatchOpTest:
+		v, ok := jsonPointerGet(doc, path)
+		if !ok {
+			return nil, fmt.Errorf("path not found")
+		}
+
+		if !yamlNodeEqual(v, jsonPatchValue(op.Value)) {
+			return nil, fmt.Errorf("test failed")
+		}
+
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// jsonPatchValue returns the node of value in json patch, missing value is null
+func jsonPatchValue(v *yaml.Node) *yaml.Node {
+	if v = prepareYamlNode(v); v != nil {
+		return v
+	}
+
+	return newScalarNode(nullTag, "null")
+}
+
+// parseJSONPointer parses rfc6901 json pointer into unescaped reference tokens
+func parseJSONPointer(p string) ([]string, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+
+	if p[0] != '/' {
+		return nil, fmt.Errorf("invalid json pointer %q: must start with `/`", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		if strings.IndexByte(t, '~') == -1 {
+			continue
+		}
+
+		for j := 0; j < len(t); j++ {
+			if t[j] != '~' {
+				continue
+			}
+
+			if j+1 == len(t) || (t[j+1] != '0' && t[j+1] != '1') {
+				return nil, fmt.Errorf("invalid json pointer %q: bad escape in %q", p, t)
+			}
+
+			j++
+		}
+
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// jsonPointerGet returns the node at path
+func jsonPointerGet(doc *yaml.Node, path []string) (*yaml.Node, bool) {
+	n := prepareYamlNode(doc)
+	for _, token := range path {
+		if n == nil {
+			return nil, false
+		}
+
+		switch n.Kind {
+		case yaml.MappingNode:
+			i := yamlMapKeyIndex(n, token)
+			if i == -1 {
+				return nil, false
+			}
+
+			n = prepareYamlNode(n.Content[i+1])
+		case yaml.SequenceNode:
+			idx, ok := jsonPatchListIndex(token, len(n.Content))
+			if !ok {
+				return nil, false
+			}
+
+			n = prepareYamlNode(n.Content[idx])
+		default:
+			return nil, false
+		}
+	}
+
+	return n, n != nil
+}
+
+func jsonPatchAdd(doc *yaml.Node, path []string, v *yaml.Node) (*yaml.Node, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	parent, ok := jsonPointerGet(doc, path[:len(path)-1])
+	if !ok {
+		return nil, fmt.Errorf("path not found")
+	}
+
+	key := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if i := yamlMapKeyIndex(parent, key); i != -1 {
+			parent.Content[i+1] = v
+		} else {
+			parent.Content = append(parent.Content, newScalarNode(strTag, key), v)
+		}
+	case yaml.SequenceNode:
+		size := len(parent.Content)
+		if key == "-" {
+			parent.Content = append(parent.Content, v)
+			break
+		}
+
+		// inserting at size is allowed
+		idx, ok := jsonPatchListIndex(key, size+1)
+		if !ok {
+			return nil, fmt.Errorf("invalid list index %q for list of size %d", key, size)
+		}
+
+		parent.Content = append(parent.Content, nil)
+		copy(parent.Content[idx+1:], parent.Content[idx:])
+		parent.Content[idx] = v
+	default:
+		return nil, fmt.Errorf("cannot add to non map/list data")
+	}
+
+	return doc, nil
+}
+
+// jsonPatchRemove removes the node at path and returns it, it's a no-op when the
+// path does not exist
+func jsonPatchRemove(doc *yaml.Node, path []string) *yaml.Node {
+	parent, ok := jsonPointerGet(doc, path[:len(path)-1])
+	if !ok {
+		return nil
+	}
+
+	key := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		i := yamlMapKeyIndex(parent, key)
+		if i == -1 {
+			return nil
+		}
+
+		v := parent.Content[i+1]
+		parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+		return v
+	case yaml.SequenceNode:
+		idx, ok := jsonPatchListIndex(key, len(parent.Content))
+		if !ok {
+			return nil
+		}
+
+		v := parent.Content[idx]
+		parent.Content = append(parent.Content[:idx], parent.Content[idx+1:]...)
+		return v
+	default:
+		return nil
+	}
+}
+
+func jsonPatchReplace(parent *yaml.Node, key string, v *yaml.Node) error {
+	switch parent.Kind {
+	case yaml.MappingNode:
+		i := yamlMapKeyIndex(parent, key)
+		if i == -1 {
+			return fmt.Errorf("path not found")
+		}
+
+		parent.Content[i+1] = v
+	case yaml.SequenceNode:
+		idx, ok := jsonPatchListIndex(key, len(parent.Content))
+		if !ok {
+			return fmt.Errorf("invalid list index %q for list of size %d", key, len(parent.Content))
+		}
+
+		parent.Content[idx] = v
+	default:
+		return fmt.Errorf("path not found")
+	}
+
+	return nil
+}
+
+// jsonPatchListIndex parses list index token, negative index counts from size
+func jsonPatchListIndex(token string, size int) (int, bool) {
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, false
+	}
+
+	if idx < 0 {
+		idx += size
+	}
+
+	return idx, idx >= 0 && idx < size
+}
+
+// yamlMapKeyIndex returns index of the key node in map node n, -1 if not found
+func yamlMapKeyIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := prepareYamlNode(n.Content[i]); k != nil && k.Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func deepCopyYamlNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+
+	ret := *n
+	if len(n.Content) != 0 {
+		ret.Content = make([]*yaml.Node, len(n.Content))
+		for i, c := range n.Content {
+			ret.Content[i] = deepCopyYamlNode(c)
+		}
+	}
+
+	return &ret
+}
+
+// yamlNodeEqual checks whether a and b are equal as json values, that is, map key
+// order is ignored and numbers are compared by value
+func yamlNodeEqual(a, b *yaml.Node) bool {
+	a, b = prepareYamlNode(a), prepareYamlNode(b)
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if a.Kind != b.Kind {
+		return false
+	}
+
+	switch a.Kind {
+	case yaml.MappingNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			j := yamlMapKeyIndex(b, prepareYamlNode(a.Content[i]).Value)
+			if j == -1 || !yamlNodeEqual(a.Content[i+1], b.Content[j+1]) {
+				return false
+			}
+		}
+
+		return true
+	case yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+
+		for i := range a.Content {
+			if !yamlNodeEqual(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		var va, vb any
+		if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+			return false
+		}
+
+		ia, fa, aIsInt, aOK := toNumber(va)
+		ib, fb, bIsInt, bOK := toNumber(vb)
+		switch {
+		case aOK && bOK && aIsInt && bIsInt:
+			return ia == ib
+		case aOK && bOK:
+			return fa == fb
+		default:
+			return reflect.DeepEqual(va, vb)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	for _, test := range []struct {
+		name string
+
+		doc   string
+		patch string
+
+		expected string
+		err      string
+	}{
+		// test cases from rfc6902 appendix A
+		{
+			name:     "Add Object Member",
+			doc:      `{ foo: bar }`,
+			patch:    `[{ op: add, path: /baz, value: qux }]`,
+			expected: `{ foo: bar, baz: qux }`,
+		},
+		{
+			name:     "Add Array Element",
+			doc:      `{ foo: [bar, baz] }`,
+			patch:    `[{ op: add, path: /foo/1, value: qux }]`,
+			expected: `{ foo: [bar, qux, baz] }`,
+		},
+		{
+			name:     "Remove Object Member",
+			doc:      `{ baz: qux, foo: bar }`,
+			patch:    `[{ op: remove, path: /baz }]`,
+			expected: `{ foo: bar }`,
+		},
+		{
+			name:     "Remove Array Element",
+			doc:      `{ foo: [bar, qux, baz] }`,
+			patch:    `[{ op: remove, path: /foo/1 }]`,
+			expected: `{ foo: [bar, baz] }`,
+		},
+		{
+			name:     "Replace Value",
+			doc:      `{ baz: qux, foo: bar }`,
+			patch:    `[{ op: replace, path: /baz, value: boo }]`,
+			expected: `{ baz: boo, foo: bar }`,
+		},
+		{
+			name:     "Move Value",
+			doc:      `{ foo: { bar: baz, waldo: fred }, qux: { corge: grault } }`,
+			patch:    `[{ op: move, from: /foo/waldo, path: /qux/thud }]`,
+			expected: `{ foo: { bar: baz }, qux: { corge: grault, thud: fred } }`,
+		},
+		{
+			name:     "Move Array Element",
+			doc:      `{ foo: [all, grass, cows, eat] }`,
+			patch:    `[{ op: move, from: /foo/1, path: /foo/3 }]`,
+			expected: `{ foo: [all, cows, eat, grass] }`,
+		},
+		{
+			name:     "Test Value Success",
+			doc:      `{ baz: qux, foo: [a, 2, c] }`,
+			patch:    `[{ op: test, path: /baz, value: qux }, { op: test, path: /foo/1, value: 2.0 }]`,
+			expected: `{ baz: qux, foo: [a, 2, c] }`,
+		},
+		{
+			name:  "Test Value Error",
+			doc:   `{ baz: qux }`,
+			patch: `[{ op: test, path: /baz, value: bar }]`,
+			err:   "test failed",
+		},
+		{
+			name:     "Add Nested Member",
+			doc:      `{ foo: bar }`,
+			patch:    `[{ op: add, path: /child, value: { grandchild: {} } }]`,
+			expected: `{ foo: bar, child: { grandchild: {} } }`,
+		},
+		{
+			name:  "Add To Nonexistent Target",
+			doc:   `{ foo: bar }`,
+			patch: `[{ op: add, path: /baz/bat, value: qux }]`,
+			err:   "path not found",
+		},
+		{
+			name:     "Escape Ordering",
+			doc:      `{ "/": 9, "~1": 10 }`,
+			patch:    `[{ op: test, path: /~01, value: 10 }, { op: replace, path: /~1, value: 8 }]`,
+			expected: `{ "/": 8, "~1": 10 }`,
+		},
+		{
+			name:  "Comparing Strings And Numbers",
+			doc:   `{ "/": 9, "~1": 10 }`,
+			patch: `[{ op: test, path: /~01, value: "10" }]`,
+			err:   "test failed",
+		},
+		{
+			name:     "Add Array Value",
+			doc:      `{ foo: [bar] }`,
+			patch:    `[{ op: add, path: /foo/-, value: [abc, def] }]`,
+			expected: `{ foo: [bar, [abc, def]] }`,
+		},
+
+		{
+			name:     "Negative Index",
+			doc:      `[a, b, c]`,
+			patch:    `[{ op: add, path: /-1, value: d }, { op: replace, path: /-2, value: e }, { op: remove, path: /-4 }]`,
+			expected: `[b, e, d]`,
+		},
+		{
+			name:     "Copy",
+			doc:      `{ a: { b: [1] } }`,
+			patch:    `[{ op: copy, from: /a, path: /c }, { op: add, path: /c/b/-, value: 2 }]`,
+			expected: `{ a: { b: [1] }, c: { b: [1, 2] } }`,
+		},
+		{
+			name:     "Replace Root",
+			doc:      `{ a: b }`,
+			patch:    `[{ op: replace, path: "", value: [a] }, { op: add, path: /0, value: null }]`,
+			expected: `[null, a]`,
+		},
+		{
+			name:     "Remove Missing",
+			doc:      `{ a: [b] }`,
+			patch:    `[{ op: remove, path: /b/c }, { op: remove, path: /a/1 }]`,
+			expected: `{ a: [b] }`,
+		},
+		{
+			name:     "Keep Order And Tags",
+			doc:      `{ z: 1.0, b: !!binary Zm9v, a: "1" }`,
+			patch:    `[{ op: add, path: /m, value: 2.0 }, { op: replace, path: /z, value: 1 }]`,
+			expected: `{ z: 1, b: !!binary Zm9v, a: "1", m: 2.0 }`,
+		},
+		{
+			name:  "Move Into Child",
+			doc:   `{ a: { b: c } }`,
+			patch: `[{ op: move, from: /a, path: /a/b }]`,
+			err:   "into its child",
+		},
+		{
+			name:  "Replace Missing",
+			doc:   `{ a: b }`,
+			patch: `[{ op: replace, path: /c, value: d }]`,
+			err:   "path not found",
+		},
+		{
+			name:  "Index Out Of Range",
+			doc:   `[a]`,
+			patch: `[{ op: add, path: /2, value: b }]`,
+			err:   "invalid list index",
+		},
+		{
+			name:  "Invalid Pointer",
+			doc:   `{ a: b }`,
+			patch: `[{ op: add, path: a, value: b }]`,
+			err:   "must start with",
+		},
+		{
+			name:  "Invalid Escape",
+			doc:   `{ a: b }`,
+			patch: `[{ op: add, path: /~2, value: b }]`,
+			err:   "bad escape",
+		},
+		{
+			name:  "Unsupported Operation",
+			doc:   `{ a: b }`,
+			patch: `[{ op: foo, path: /a }]`,
+			err:   `unsupported operation "foo"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				doc yaml.Node
+				ops []map[string]any
+			)
+
+			assert.NoError(t, yaml.Unmarshal([]byte(test.doc), &doc))
+			assert.NoError(t, yaml.Unmarshal([]byte(test.patch), &ops))
+
+			patch := make([]JSONPatchOperation, len(ops))
+			for i, op := range ops {
+				patch[i].Op, _ = op["op"].(string)
+				patch[i].Path, _ = op["path"].(string)
+				patch[i].From, _ = op["from"].(string)
+
+				if v, ok := op["value"]; ok {
+					var err error
+					patch[i].Value, err = anyToYamlNode(v)
+					assert.NoError(t, err)
+				}
+			}
+
+			ret, err := ApplyJSONPatch(&doc, patch)
+			if len(test.err) != 0 {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var expected yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(test.expected), &expected))
+			assert.Equal(t, formatFlowYaml(t, &expected), formatFlowYaml(t, ret))
+		})
+	}
+}
+
+// formatFlowYaml marshals n in flow style, to compare yaml nodes with order and tags
+func formatFlowYaml(t *testing.T, n *yaml.Node) string {
+	var setStyle func(n *yaml.Node)
+	setStyle = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode, yaml.SequenceNode:
+			n.Style = yaml.FlowStyle
+		case yaml.ScalarNode:
+			n.Style &^= yaml.FlowStyle
+		}
+
+		for _, c := range n.Content {
+			setStyle(c)
+		}
+	}
+
+	n = prepareYamlNode(n)
+	setStyle(n)
+
+	data, err := yaml.Marshal(n)
+	assert.NoError(t, err)
+	return string(data)
+}
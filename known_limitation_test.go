@@ -62,4 +62,25 @@ func TestLimitation_BuiltInMapKeyNotResolved(t *testing.T) {
 		assert.NotNil(t, f.BarMap.Data["bar_key"], "now it works")
 		assert.Nil(t, f.BarMap.Data["bar_key@my-renderer"])
 	})
+
+	t.Run("keys tag", func(t *testing.T) {
+		type Foo struct {
+			rs.BaseField
+
+			BarMap map[string]*Bar `yaml:"bar_map" rs:"keys"`
+		}
+
+		f := rs.Init(&Foo{}, nil).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(yamlData), f))
+		assert.NoError(t, f.ResolveFields(
+			rs.RenderingHandleFunc(
+				func(renderer string, rawData any) (result []byte, err error) {
+					return yaml.Marshal(rawData)
+				},
+			), -1),
+		)
+
+		assert.NotNil(t, f.BarMap["bar_key"], "opt-in with `rs:\"keys\"`")
+		assert.Nil(t, f.BarMap["bar_key@my-renderer"])
+	})
 }
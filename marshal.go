@@ -6,66 +6,136 @@ package rs
 import (
 	"fmt"
 	"reflect"
+
+	"gopkg.in/yaml.v3"
 )
 
-// MarshalYAML implements yaml.Marshaler by making a map of all fields
+// MarshalYAML implements yaml.Marshaler by making a mapping node of all fields
 // known to BaseField
 //
+// fields are emitted in struct field declaration order (fields of inline structs are
+// expanded in place), items of the inline map are sorted by key and emitted where the
+// inline map field is declared, unless overridden by normal fields
+//
+// NOTE: the returned value is a *yaml.Node of kind yaml.MappingNode, it used to be a
+// map[string]any, which can not keep field order, decode it if you need a map
+//
 // You can opt-out with build tag `rs_noyamlmarshaler`
 func (f *BaseField) MarshalYAML() (any, error) {
 	if !f.initialized() {
 		return nil, fmt.Errorf("rs: struct not intialized before marshaling")
 	}
 
-	ret := make(map[string]any)
+	ret := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+	appendPair := func(key string, value *yaml.Node) {
+		ret.Content = append(ret.Content, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   strTag,
+			Value: key,
+		}, value)
+	}
+
+	unresolved := f._opts != nil && f._opts.MarshalUnresolvedFields
+	if unresolved {
+		// virtual key first, so values of other keys are not overridden
+		// by value resolved from it
+		for i := range f.unresolvedSelfItems {
+			v := &f.unresolvedSelfItems[i]
+			appendPair("__@"+formatRenderingSuffix(v.renderers), v.rawData)
+		}
+	}
+
+	for _, k := range f.yamlKeys() {
+		ref, isField := f.normalFields[k]
+		if unresolved {
+			if isField {
+				if v, ok := ref.base.unresolvedNormalFields[k]; ok {
+					appendPair(yamlKeyWithSuffix(k, v.renderers), v.rawData)
+					continue
+				}
+			} else if list, ok := f.unresolvedInlineMapItems[k]; ok {
+				// overlapped items are kept in order
+				for _, v := range list {
+					// raw data of inline map item is a fake map of the key and value
+					appendPair(yamlKeyWithSuffix(k, v.renderers), v.rawData.Content[1])
+				}
+				continue
+			}
+		}
+
+		var fv reflect.Value
+		if isField {
+			if ref.omitted() {
+				continue
+			}
+
+			fv = ref.fieldValue
+		} else {
+			// NOTE: we MUST not use catch other cache since user can
+			// 		 access map directly without updating our cache
+			fv = f.inlineMap.fieldValue.MapIndex(reflect.ValueOf(k).Convert(
+				f.inlineMap.fieldValue.Type().Key(),
+			))
 
-	// handle catch other fields first, so they can be overridden
-	// by normal fields
-	if f.inlineMap != nil && f.inlineMap.fieldValue.IsValid() {
-		// NOTE: we MUST not use catch other cache since user can
-		// 		 access map directly without updating our cache
-		iter := f.inlineMap.fieldValue.MapRange()
-		for iter.Next() {
 			// always include it no matter what value it has
 			//
 			// if not, unmarshal using our output will give
 			// different result
-			k, v := iter.Key().String(), iter.Value()
-
-			if v.Kind() == reflect.Ptr && v.IsNil() {
-				ret[k] = nil
+			if !fv.IsValid() {
+				// unresolved inline map item
 				continue
 			}
+		}
 
-			ret[k] = v.Interface()
+		var v any
+		switch {
+		case fv.Kind() == reflect.Ptr && fv.IsNil():
+		case fv.Kind() == reflect.Struct && fv.CanAddr():
+			// use pointer to make use of MarshalYAML of nested BaseField, encoding the
+			// struct value by reflection emits BaseField as an extra `basefield` key
+			v = fv.Addr().Interface()
+		default:
+			v = fv.Interface()
 		}
-	}
 
-	for k, v := range f.normalFields {
-		vk := v.fieldValue.Kind()
+		value := &yaml.Node{}
+		err := value.Encode(v)
+		if err != nil {
+			return nil, fmt.Errorf("rs: marshal value of %q: %w", k, err)
+		}
 
-		if v.omitempty {
-			if !v.fieldValue.IsValid() || (vk != reflect.Array && v.fieldValue.IsZero()) {
-				// value not set or zero value, just ignore it
-				continue
-			}
+		if unresolved && isField && len(ref.base.unresolvedMapKeyItems[k]) != 0 {
+			value = marshalUnresolvedMapKeyItems(value, ref.base.unresolvedMapKeyItems[k])
+		}
 
-			// value already set and not zero value
-			switch vk {
-			case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
-				if v.fieldValue.Len() == 0 {
-					continue
-				}
+		appendPair(k, value)
+	}
+
+	return ret, nil
+}
+
+// marshalUnresolvedMapKeyItems replaces entries of the map field with `rs:"keys"` tag
+// by their yaml keys with rendering suffix and raw values
+func marshalUnresolvedMapKeyItems(value *yaml.Node, items map[string][]unresolvedFieldSpec) *yaml.Node {
+	ret := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+	if value.Kind == yaml.MappingNode {
+		ret.Style = value.Style
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if _, ok := items[value.Content[i].Value]; !ok {
+				ret.Content = append(ret.Content, value.Content[i], value.Content[i+1])
 			}
 		}
+	}
 
-		if vk == reflect.Ptr && v.fieldValue.IsNil() {
-			ret[k] = nil
-			continue
+	for _, k := range sortedKeys(items) {
+		for _, v := range items[k] {
+			ret.Content = append(ret.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   strTag,
+				Value: yamlKeyWithSuffix(k, v.renderers),
+			}, v.rawData.Content[1])
 		}
-
-		ret[k] = v.fieldValue.Interface()
 	}
 
-	return ret, nil
+	return ret
 }
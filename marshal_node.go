@@ -0,0 +1,344 @@
+package rs
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NodeMarshaler is implemented by BaseField to marshal itself as *yaml.Node
+type NodeMarshaler interface {
+	MarshalNode() (*yaml.Node, error)
+}
+
+// MarshalNode makes a yaml mapping node of current field values
+//
+// when the struct was unmarshaled from yaml with Options.KeepSourceNode set, yaml nodes
+// of the source document are reused for unchanged values (including fields with rendering
+// suffix), so key order, comments, anchors and scalar styles are kept, new keys are appended
+// after existing ones in the same order as MarshalYAML
+//
+// otherwise, all keys are emitted in the same order as MarshalYAML with new nodes
+//
+// NOTE: returned node may share child nodes with the source document
+func (f *BaseField) MarshalNode() (*yaml.Node, error) {
+	if !f.initialized() {
+		return nil, fmt.Errorf("rs: struct not intialized before marshaling")
+	}
+
+	ret := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+
+	var (
+		// yaml keys already emitted
+		done = make(map[string]struct{})
+
+		// values in source document after merging (`<<`)
+		sourceValues = make(map[string]*yaml.Node)
+	)
+
+	if src := f.sourceNode; src != nil {
+		ret.Style = src.Style
+		ret.Anchor = src.Anchor
+		ret.HeadComment, ret.LineComment, ret.FootComment = src.HeadComment, src.LineComment, src.FootComment
+
+		pairs, err := unmarshalYamlMap(src.Content)
+		if err != nil {
+			return nil, fmt.Errorf("rs: invalid source node: %w", err)
+		}
+
+		for _, kv := range pairs {
+			sourceValues[kv[0].Value] = kv[1]
+		}
+
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			k, v := src.Content[i], src.Content[i+1]
+
+			yamlKey, keep := f.sourceYamlKey(k, v)
+			done[yamlKey] = struct{}{}
+			if keep {
+				ret.Content = append(ret.Content, k, v)
+				continue
+			}
+
+			value, ok, err := f.marshalValueNode(yamlKey, v)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				ret.Content = append(ret.Content, k, value)
+			}
+		}
+	}
+
+	// keys not in source document, in struct field declaration order
+	for _, k := range f.yamlKeys() {
+		if _, ok := done[k]; ok {
+			continue
+		}
+		done[k] = struct{}{}
+
+		if ref, ok := f.normalFields[k]; ok {
+			// field with rendering suffix not from source document (e.g. added by Inherit)
+			if spec, ok := ref.base.unresolvedNormalFields[k]; ok {
+				ret.Content = append(ret.Content, &yaml.Node{
+					Kind:  yaml.ScalarNode,
+					Tag:   strTag,
+					Value: yamlKeyWithSuffix(k, spec.renderers),
+				}, spec.rawData)
+
+				continue
+			}
+		}
+
+		src := sourceValues[k]
+		value, ok, err := f.marshalValueNode(k, src)
+		if err != nil {
+			return nil, err
+		}
+
+		// skip values provided by merge key when not changed
+		if !ok || (src != nil && value == src) {
+			continue
+		}
+
+		ret.Content = append(ret.Content, &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   strTag,
+			Value: k,
+		}, value)
+	}
+
+	return ret, nil
+}
+
+// sourceYamlKey returns the yaml key of the key value pair in source document, and
+// whether to keep the pair as is (using rendering suffix, merge key or unknown field)
+func (f *BaseField) sourceYamlKey(k, v *yaml.Node) (yamlKey string, keep bool) {
+	rawYamlKey := k.Value
+
+	switch {
+	case isMerge(k):
+		return rawYamlKey, true
+	case strings.HasPrefix(v.Tag, "!rs:"), strings.HasPrefix(v.Tag, "!tag:arhat.dev/rs:"):
+		return rawYamlKey, true
+	}
+
+	if _, ok := f.getField(rawYamlKey); ok {
+		return rawYamlKey, false
+	}
+
+	if idx := renderingSuffixStart(rawYamlKey); idx != -1 {
+		return rawYamlKey[:idx], true
+	}
+
+	if f.inlineMap == nil {
+		// unknown field
+		return rawYamlKey, true
+	}
+
+	return rawYamlKey, false
+}
+
+// marshalValueNode makes yaml node for the value of yamlKey, src is reused when the value
+// is not changed
+//
+// ok is false when the value should be omitted
+func (f *BaseField) marshalValueNode(yamlKey string, src *yaml.Node) (_ *yaml.Node, ok bool, err error) {
+	var fv reflect.Value
+	ref, isField := f.normalFields[yamlKey]
+	if isField {
+		if ref.omitted() {
+			return nil, false, nil
+		}
+
+		fv = ref.fieldValue
+	} else {
+		if f.inlineMap == nil || !f.inlineMap.fieldValue.IsValid() || f.inlineMap.fieldValue.IsNil() {
+			return nil, false, nil
+		}
+
+		fv = f.inlineMap.fieldValue.MapIndex(reflect.ValueOf(yamlKey).Convert(
+			f.inlineMap.fieldValue.Type().Key(),
+		))
+		if !fv.IsValid() {
+			// removed from inline map
+			return nil, false, nil
+		}
+	}
+
+	ret, err := marshalNodeReusing(fv, src, f._opts)
+	if err != nil {
+		return nil, false, fmt.Errorf("rs: marshal value of %q: %w", yamlKey, err)
+	}
+
+	if isField && ref.renderKeys {
+		ret = withMapKeyItems(ret, src, ref.base.unresolvedMapKeyItems[yamlKey])
+	}
+
+	return ret, true, nil
+}
+
+// withMapKeyItems puts items with rendering suffix in keys of the map field with `rs:"keys"`
+// tag back into value, key value pairs of these items in src are reused in the original order
+func withMapKeyItems(value, src *yaml.Node, items map[string][]unresolvedFieldSpec) *yaml.Node {
+	if len(items) == 0 {
+		return value
+	}
+
+	ret := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag}
+
+	// items without rendering suffix, resolved values of items are replaced by raw ones
+	var (
+		plainKeys []string
+		plain     = make(map[string][2]*yaml.Node)
+	)
+
+	if value.Kind == yaml.MappingNode {
+		ret.Style = value.Style
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			k := value.Content[i].Value
+			if _, ok := items[k]; !ok {
+				plainKeys = append(plainKeys, k)
+				plain[k] = [2]*yaml.Node{value.Content[i], value.Content[i+1]}
+			}
+		}
+	}
+
+	copyNodeComments(ret, value)
+
+	reused := make(map[*yaml.Node]struct{})
+	if src = prepareYamlNode(src); src != nil && src.Kind == yaml.MappingNode {
+		ret.Style = src.Style
+		copyNodeComments(ret, src)
+
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			k := src.Content[i]
+			if idx := renderingSuffixStart(k.Value); idx != -1 {
+				for _, spec := range items[k.Value[:idx]] {
+					if spec.key == k {
+						ret.Content = append(ret.Content, k, src.Content[i+1])
+						reused[k] = struct{}{}
+						break
+					}
+				}
+
+				continue
+			}
+
+			if kv, ok := plain[k.Value]; ok {
+				ret.Content = append(ret.Content, kv[0], kv[1])
+				delete(plain, k.Value)
+			}
+		}
+	}
+
+	for _, k := range plainKeys {
+		if kv, ok := plain[k]; ok {
+			ret.Content = append(ret.Content, kv[0], kv[1])
+		}
+	}
+
+	// items not in src (e.g. from merge key)
+	for _, k := range sortedKeys(items) {
+		for _, spec := range items[k] {
+			if _, ok := reused[spec.key]; ok {
+				continue
+			}
+
+			ret.Content = append(ret.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   strTag,
+				Value: yamlKeyWithSuffix(k, spec.renderers),
+			}, spec.rawData.Content[1])
+		}
+	}
+
+	return ret
+}
+
+// marshalNodeReusing makes yaml node for fv, src is returned when fv is equivalent to it
+func marshalNodeReusing(fv reflect.Value, src *yaml.Node, opts *Options) (*yaml.Node, error) {
+	// nested structs reuse their own source nodes
+	var nm NodeMarshaler
+	switch {
+	case fv.Kind() == reflect.Struct && fv.CanAddr():
+		nm, _ = fv.Addr().Interface().(NodeMarshaler)
+	case fv.Kind() == reflect.Ptr && !fv.IsNil():
+		nm, _ = fv.Interface().(NodeMarshaler)
+	}
+
+	if nm != nil {
+		if bf, ok := nm.(interface{ initialized() bool }); !ok || bf.initialized() {
+			ret, err := nm.MarshalNode()
+			if err != nil {
+				return nil, err
+			}
+
+			copyNodeComments(ret, src)
+			return ret, nil
+		}
+	}
+
+	var value any
+	if fv.IsValid() && fv.CanInterface() {
+		value = fv.Interface()
+	}
+
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if src != nil && isEquivalent(data, fv.Type(), src, opts) {
+		return src, nil
+	}
+
+	ret := &yaml.Node{}
+	err = yaml.Unmarshal(data, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	ret = prepareYamlNode(ret)
+	if ret == nil {
+		ret = &yaml.Node{Kind: yaml.ScalarNode, Tag: nullTag, Value: "null"}
+	}
+
+	if src != nil && src.Kind == ret.Kind && src.Kind == yaml.ScalarNode && src.ShortTag() == ret.ShortTag() {
+		// keep the scalar style (e.g. quoted string)
+		ret.Style = src.Style
+	}
+
+	copyNodeComments(ret, src)
+	return ret, nil
+}
+
+// isEquivalent checks whether data is the same as yaml encoded value of src decoded as typ
+func isEquivalent(data []byte, typ reflect.Type, src *yaml.Node, opts *Options) bool {
+	decoded := reflect.New(typ)
+	InitRecursively(decoded, opts)
+
+	err := src.Decode(decoded.Interface())
+	if err != nil {
+		return false
+	}
+
+	srcData, err := yaml.Marshal(decoded.Elem().Interface())
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(data, srcData)
+}
+
+func copyNodeComments(out, src *yaml.Node) {
+	if src == nil {
+		return
+	}
+
+	out.HeadComment, out.LineComment, out.FootComment = src.HeadComment, src.LineComment, src.FootComment
+}
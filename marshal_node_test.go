@@ -0,0 +1,172 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var _ NodeMarshaler = (*BaseField)(nil)
+
+func TestBaseField_MarshalNode(t *testing.T) {
+	type Inner struct {
+		BaseField
+
+		Cmd string `yaml:"cmd"`
+		Env string `yaml:"env"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Str   string            `yaml:"str"`
+		Num   int               `yaml:"num"`
+		List  []string          `yaml:"list"`
+		Copy  []string          `yaml:"copy,omitempty"`
+		Inner Inner             `yaml:"inner"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	const input = `# head comment
+str: "quoted" # line comment
+num: 0x10
+list: &anchor [a, b]
+inner:
+    # inner comment
+    cmd: 'x'
+    env@echo: e
+other@echo: o
+plain: p
+removed: r
+`
+
+	f := Init(&Foo{}, &Options{KeepSourceNode: true}).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+
+	t.Run("Unchanged", func(t *testing.T) {
+		n, err := f.MarshalNode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ret, err := yaml.Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, input, string(ret))
+	})
+
+	t.Run("Changed", func(t *testing.T) {
+		f.Str = "changed"
+		f.Copy = []string{"c"}
+		delete(f.Other, "removed")
+		f.Other["added"] = "d"
+
+		n, err := f.MarshalNode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ret, err := yaml.Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, `# head comment
+str: "changed" # line comment
+num: 0x10
+list: &anchor [a, b]
+inner:
+    # inner comment
+    cmd: 'x'
+    env@echo: e
+other@echo: o
+plain: p
+copy:
+    - c
+added: d
+`, string(ret))
+	})
+
+	t.Run("Source Node Not Kept", func(t *testing.T) {
+		v := Init(&Foo{}, nil).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(input), v))
+		assert.Nil(t, v.sourceNode)
+		assert.Nil(t, v.Inner.sourceNode)
+
+		n, err := v.MarshalNode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ret, err := yaml.Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, `str: quoted
+num: 16
+list:
+    - a
+    - b
+inner:
+    cmd: x
+    env@echo: e
+plain: p
+removed: r
+`, string(ret))
+	})
+
+	t.Run("Not Unmarshaled", func(t *testing.T) {
+		v := Init(&Foo{Str: "a", Num: 1}, nil).(*Foo)
+		n, err := v.MarshalNode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ret, err := yaml.Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, "str: a\nnum: 1\nlist: []\ninner:\n    cmd: \"\"\n    env: \"\"\n", string(ret))
+	})
+}
+
+func TestBaseField_MarshalNode_renderKeys(t *testing.T) {
+	type Bar struct {
+		BaseField
+
+		Data string `yaml:"data"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Bars map[string]*Bar `yaml:"bars" rs:"keys"`
+	}
+
+	const input = "bars: {a: {data: a}, b@echo: {data: b}}\n"
+
+	for _, keep := range []bool{true, false} {
+		f := Init(&Foo{}, &Options{KeepSourceNode: keep}).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+
+		for _, resolve := range []bool{false, true} {
+			if resolve {
+				assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+				assert.Equal(t, "b", f.Bars["b"].Data)
+			}
+
+			n, err := f.MarshalNode()
+			if !assert.NoError(t, err) {
+				continue
+			}
+
+			ret, err := yaml.Marshal(n)
+			assert.NoError(t, err)
+
+			out := Init(&Foo{}, nil).(*Foo)
+			assert.NoError(t, yaml.Unmarshal(ret, out), string(ret))
+			assert.NoError(t, out.ResolveFields(testRenderingHandler{}, -1))
+			if assert.Len(t, out.Bars, 2, string(ret)) {
+				assert.Equal(t, "a", out.Bars["a"].Data)
+				assert.Equal(t, "b", out.Bars["b"].Data)
+			}
+
+			if keep {
+				assert.Equal(t, input, string(ret))
+			}
+		}
+	}
+}
@@ -112,6 +112,88 @@ func TestBaseField_MarshalYAML_uninitialized(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBaseField_MarshalYAML_node(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		B     string            `yaml:"b"`
+		A     string            `yaml:"a"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`{ a: 1, b: 2, c: 3 }`), f))
+
+	ret, err := f.MarshalYAML()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// used to be map[string]any
+	n, ok := ret.(*yaml.Node)
+	if !assert.True(t, ok, "unexpected %T", ret) {
+		return
+	}
+
+	assert.Equal(t, yaml.MappingNode, n.Kind)
+
+	var keys []string
+	for i := 0; i < len(n.Content); i += 2 {
+		keys = append(keys, n.Content[i].Value)
+	}
+	assert.Equal(t, []string{"b", "a", "c"}, keys)
+
+	var m map[string]any
+	assert.NoError(t, n.Decode(&m))
+	assert.Equal(t, map[string]any{"a": "1", "b": "2", "c": "3"}, m)
+}
+
+func TestBaseField_MarshalYAML_nestedStruct(t *testing.T) {
+	type Inner struct {
+		BaseField
+
+		A     string            `yaml:"a"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		In Inner `yaml:"in"`
+	}
+
+	const (
+		input = `in: { a: x, b: y }`
+
+		// non-pointer struct fields used to be encoded by reflection, the exported but
+		// field-less BaseField was emitted as an extra key, and unmarshaling the output
+		// again failed
+		oldOutput = `
+in:
+    basefield: {}
+    a: x
+    b: "y"
+`
+
+		// they are encoded by MarshalYAML of the nested BaseField now, as pointer
+		// struct fields always are
+		expected = `in:
+    a: x
+    b: "y"
+`
+	)
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+
+	ret, err := yaml.Marshal(f)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(ret))
+
+	assert.NoError(t, yaml.Unmarshal(ret, Init(&Foo{}, nil)))
+	assert.Error(t, yaml.Unmarshal([]byte(oldOutput), Init(&Foo{}, nil)))
+}
+
 func TestBaseField_MarshalYAML_primitive(t *testing.T) {
 	var allTests []marshalTestSpec
 
@@ -505,11 +587,11 @@ func TestBaseField_MarshalYAML(t *testing.T) {
 			inputNoRS:   "{ bar: foo, a: b, c: d}",
 			inputWithRS: "{ bar@echo: foo, a@echo: b, c@echo: d}",
 
-			equivalent: map[string]string{
-				"bar": "foo",
-				"a":   "b",
-				"c":   "d",
-			},
+			equivalent: &struct {
+				Bar string `yaml:"bar"`
+
+				Data map[string]string `yaml:",inline"`
+			}{Bar: "foo", Data: map[string]string{"a": "b", "c": "d"}},
 		},
 		{
 			name: "Catch Other Not Nil",
@@ -553,3 +635,135 @@ func TestBaseField_MarshalYAML(t *testing.T) {
 
 	runMarshalTest(t, tests)
 }
+
+func TestOptions_MarshalUnresolvedFields(t *testing.T) {
+	type Inner struct {
+		BaseField
+
+		Cmd string `yaml:"cmd"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Str   string            `yaml:"str"`
+		Num   int               `yaml:"num"`
+		List  []string          `yaml:"list"`
+		Inner Inner             `yaml:"inner"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	const input = `
+str@echo: a
+num: 1
+list@echo|echo?[]obj!:
+  value: [a]
+inner:
+  cmd@echo: b
+other@echo: c
+other@echo: d
+plain: e
+`
+
+	const expected = `str@echo: a
+num: 1
+list@echo|echo?[]obj!:
+    value: [a]
+inner:
+    cmd@echo: b
+other@echo: c
+other@echo: d
+plain: e
+`
+
+	opts := &Options{MarshalUnresolvedFields: true}
+
+	f := Init(&Foo{}, opts).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+
+	ret, err := yaml.Marshal(f)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(ret))
+
+	// output is still resolvable to the same value
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+	actual := Init(&Foo{}, opts).(*Foo)
+	assert.NoError(t, yaml.Unmarshal(ret, actual))
+	assert.NoError(t, actual.ResolveFields(testRenderingHandler{}, -1))
+
+	assert.Equal(t, f.Str, actual.Str)
+	assert.Equal(t, f.Num, actual.Num)
+	assert.Equal(t, f.List, actual.List)
+	assert.Equal(t, f.Inner.Cmd, actual.Inner.Cmd)
+	assert.Equal(t, f.Other, actual.Other)
+
+	// resolved values are not baked into the output
+	ret, err = yaml.Marshal(f)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(ret))
+
+	t.Run("Virtual Key", func(t *testing.T) {
+		type Bar struct {
+			BaseField
+
+			Str string `yaml:"str"`
+		}
+
+		b := Init(&Bar{}, opts).(*Bar)
+		assert.NoError(t, yaml.Unmarshal([]byte("__@echo: {str: a}\nstr: b"), b))
+
+		ret, err := yaml.Marshal(b)
+		assert.NoError(t, err)
+		assert.Equal(t, "__@echo: {str: a}\nstr: b\n", string(ret))
+	})
+}
+
+func TestBaseField_MarshalYAML_order(t *testing.T) {
+	type Inner struct {
+		BaseField
+
+		B string `yaml:"b"`
+		A string `yaml:"a"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Z     string            `yaml:"z"`
+		Inner Inner             `yaml:",inline"`
+		Other map[string]string `yaml:",inline"`
+		Y     string            `yaml:"y"`
+		X     *Inner            `yaml:"x"`
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+x: { a: 1, b: 2 }
+d: 3
+y: 4
+c: 5
+a: 6
+z: 7
+b: 8
+`), f))
+
+	f.Other["y"] = "overridden"
+
+	const expected = `z: "7"
+b: "8"
+a: "6"
+c: "5"
+d: "3"
+y: "4"
+x:
+    b: "2"
+    a: "1"
+`
+
+	for i := 0; i < 10; i++ {
+		ret, err := yaml.Marshal(f)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(ret))
+	}
+}
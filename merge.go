@@ -0,0 +1,594 @@
+package rs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeStrategy is the policy of merging values at some path, set it in
+// PatchSpec.MergeStrategies or MergeOptions.Strategies
+type MergeStrategy string
+
+// nolint:revive
+const (
+	// MergeStrategyReplace replaces the existing value
+	MergeStrategyReplace MergeStrategy = "replace"
+
+	// MergeStrategyAppend appends items to the existing list
+	MergeStrategyAppend MergeStrategy = "append"
+
+	// MergeStrategyPrepend inserts items before the existing list
+	MergeStrategyPrepend MergeStrategy = "prepend"
+
+	// MergeStrategyUniqueAppend appends items to the existing list, duplicate items are dropped
+	MergeStrategyUniqueAppend MergeStrategy = "unique-append"
+
+	// MergeStrategyMergeByKey merges objects in lists by value of the key in parentheses
+	// (e.g. `merge-by-key(name)`), defaults to ListMergeKey when the key is not set
+	MergeStrategyMergeByKey MergeStrategy = "merge-by-key"
+
+	// MergeStrategyErrorOnConflict fails when the existing value is different, maps are
+	// still merged
+	MergeStrategyErrorOnConflict MergeStrategy = "error-on-conflict"
+
+	// MergeStrategyOverrideOnTypeMismatch replaces the existing value when its type is
+	// different (e.g. a map replaced by a list) instead of failing
+	MergeStrategyOverrideOnTypeMismatch MergeStrategy = "override-on-type-mismatch"
+)
+
+// MergeOptions are options for merging maps and lists
+type MergeOptions struct {
+	// AppendList to append lists instead of replacing existing list
+	AppendList bool
+
+	// UniqueInListItems to ensure items are unique in all merged lists respectively
+	UniqueInListItems bool
+
+	// ListMergeKey to merge objects in lists by value of this key, see PatchSpec.ListMergeKey
+	ListMergeKey string
+
+	// Strategies by path of values in JSONPath style (e.g. `$.spec.env`), overriding options above
+	//
+	// supported path segments are `.<key>`, `['<key>']`, `.*` (any key) and `[*]` (any list item)
+	Strategies map[string]MergeStrategy
+}
+
+// MergeMapWithOptions merges additional into a copy of original recursively using opts
+func MergeMapWithOptions(original, additional map[string]any, opts *MergeOptions) (map[string]any, error) {
+	m, err := newMerger(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.mergeMap(nil, original, additional)
+}
+
+// mergePathSegment is a key or a list item in the path of a value being merged
+type mergePathSegment struct {
+	key  string
+	item bool
+}
+
+func (s mergePathSegment) child(path []mergePathSegment) []mergePathSegment {
+	return append(path[:len(path):len(path)], s)
+}
+
+func formatMergePath(path []mergePathSegment) string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, seg := range path {
+		if seg.item {
+			sb.WriteString("[*]")
+		} else {
+			sb.WriteString(".")
+			sb.WriteString(seg.key)
+		}
+	}
+
+	return sb.String()
+}
+
+// mergePathPattern is a parsed path of MergeOptions.Strategies
+type mergePathPattern struct {
+	raw      string
+	segments []mergePathSegment
+	// anyKey[i] is true when segments[i] matches any key
+	anyKey []bool
+
+	wildcards int
+
+	strategy MergeStrategy
+	key      string
+}
+
+func (p *mergePathPattern) match(path []mergePathSegment) bool {
+	if len(p.segments) != len(path) {
+		return false
+	}
+
+	for i, seg := range p.segments {
+		switch {
+		case seg.item != path[i].item:
+			return false
+		case seg.item, p.anyKey[i]:
+		case seg.key != path[i].key:
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseMergePath parses path in JSONPath style
+func parseMergePath(path string) (segments []mergePathSegment, anyKey []bool, err error) {
+	p := strings.TrimPrefix(path, "$")
+	addKey := func(key string, wildcard bool) {
+		segments = append(segments, mergePathSegment{key: key})
+		anyKey = append(anyKey, wildcard)
+	}
+
+	for len(p) != 0 {
+		switch {
+		case strings.HasPrefix(p, "[*]"):
+			segments = append(segments, mergePathSegment{item: true})
+			anyKey = append(anyKey, false)
+			p = p[3:]
+		case strings.HasPrefix(p, "['"), strings.HasPrefix(p, `["`):
+			end := strings.Index(p[2:], p[1:2]+"]")
+			if end == -1 {
+				return nil, nil, fmt.Errorf("unclosed bracket in path %q", path)
+			}
+
+			addKey(p[2:2+end], false)
+			p = p[2+end+2:]
+		case p[0] == '[':
+			return nil, nil, fmt.Errorf("unsupported list index in path %q, only [*] is supported", path)
+		default:
+			if p[0] == '.' {
+				p = p[1:]
+			} else if len(segments) != 0 {
+				return nil, nil, fmt.Errorf("invalid path %q", path)
+			}
+
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+
+			if end == 0 {
+				return nil, nil, fmt.Errorf("empty key in path %q", path)
+			}
+
+			addKey(p[:end], p[:end] == "*")
+			p = p[end:]
+		}
+	}
+
+	return
+}
+
+// parseMergeStrategy returns the strategy name and the key of merge-by-key
+func parseMergeStrategy(s MergeStrategy) (name MergeStrategy, key string, err error) {
+	name = s
+	if start := strings.IndexByte(string(s), '('); start >= 0 {
+		if !strings.HasSuffix(string(s), ")") {
+			return "", "", fmt.Errorf("unclosed parentheses in merge strategy %q", s)
+		}
+
+		name, key = s[:start], strings.TrimSpace(string(s[start+1:len(s)-1]))
+		if name != MergeStrategyMergeByKey || len(key) == 0 {
+			return "", "", fmt.Errorf("invalid merge strategy %q", s)
+		}
+	}
+
+	switch name {
+	case MergeStrategyReplace,
+		MergeStrategyAppend,
+		MergeStrategyPrepend,
+		MergeStrategyUniqueAppend,
+		MergeStrategyMergeByKey,
+		MergeStrategyErrorOnConflict,
+		MergeStrategyOverrideOnTypeMismatch:
+	default:
+		return "", "", fmt.Errorf("unknown merge strategy %q", s)
+	}
+
+	return
+}
+
+// merger merges maps and lists with parsed MergeOptions
+type merger struct {
+	opts MergeOptions
+
+	// sorted by number of wildcards, the first matched one is used
+	patterns []*mergePathPattern
+}
+
+func newMerger(opts *MergeOptions) (*merger, error) {
+	m := &merger{}
+	if opts == nil {
+		return m, nil
+	}
+
+	m.opts = *opts
+	for path, strategy := range opts.Strategies {
+		p := &mergePathPattern{raw: path}
+
+		var err error
+		p.segments, p.anyKey, err = parseMergePath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		p.strategy, p.key, err = parseMergeStrategy(strategy)
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %w", path, err)
+		}
+
+		if p.strategy == MergeStrategyMergeByKey && len(p.key) == 0 {
+			p.key = opts.ListMergeKey
+			if len(p.key) == 0 {
+				return nil, fmt.Errorf("path %q: no key for merge strategy %q", path, strategy)
+			}
+		}
+
+		for i, seg := range p.segments {
+			if seg.item || p.anyKey[i] {
+				p.wildcards++
+			}
+		}
+
+		m.patterns = append(m.patterns, p)
+	}
+
+	sort.Slice(m.patterns, func(i, j int) bool {
+		a, b := m.patterns[i], m.patterns[j]
+		if a.wildcards != b.wildcards {
+			return a.wildcards < b.wildcards
+		}
+
+		return a.raw < b.raw
+	})
+
+	return m, nil
+}
+
+// strategyFor returns the pattern matching path, nil when there is no strategy set
+func (m *merger) strategyFor(path []mergePathSegment) *mergePathPattern {
+	for _, p := range m.patterns {
+		if p.match(path) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// mergeValue merges nv into existing value ov at path
+func (m *merger) mergeValue(path []mergePathSegment, ov, nv any) (any, error) {
+	p := m.strategyFor(path)
+	if p != nil {
+		switch p.strategy {
+		case MergeStrategyReplace:
+			return nv, nil
+		case MergeStrategyOverrideOnTypeMismatch:
+			if valueKind(ov) != valueKind(nv) {
+				return nv, nil
+			}
+		case MergeStrategyErrorOnConflict:
+			om, ok1 := ov.(map[string]any)
+			nm, ok2 := nv.(map[string]any)
+			if ok1 && ok2 {
+				return m.mergeMap(path, om, nm)
+			}
+
+			if !reflect.DeepEqual(ov, nv) {
+				return nil, fmt.Errorf("conflict values at %s: %v and %v", formatMergePath(path), ov, nv)
+			}
+
+			return ov, nil
+		default:
+			// list strategies
+			ol, ok1 := ov.([]any)
+			nl, ok2 := nv.([]any)
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf(
+					"merge strategy %q at %s requires lists, got %T and %T",
+					p.strategy, formatMergePath(path), ov, nv,
+				)
+			}
+
+			return m.mergeList(path, p, ol, nl)
+		}
+	}
+
+	switch newVal := nv.(type) {
+	case map[string]any:
+		originalMap, ok := ov.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non map data at %s: %v", formatMergePath(path), ov)
+		}
+
+		return m.mergeMap(path, originalMap, newVal)
+	case []any:
+		originalList, ok := ov.([]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non list data at %s: %v", formatMergePath(path), ov)
+		}
+
+		return m.mergeList(path, nil, originalList, newVal)
+	default:
+		return newVal, nil
+	}
+}
+
+// mergeList merges lists using strategy of p, or MergeOptions when p is nil
+func (m *merger) mergeList(path []mergePathSegment, p *mergePathPattern, original, additional []any) (ret []any, err error) {
+	var strategy MergeStrategy
+	if p != nil {
+		strategy = p.strategy
+	}
+
+	switch strategy {
+	case MergeStrategyAppend:
+		ret = append(original[:len(original):len(original)], additional...)
+	case MergeStrategyPrepend:
+		ret = append(append(make([]any, 0, len(original)+len(additional)), additional...), original...)
+	case MergeStrategyUniqueAppend:
+		ret = UniqueList(append(original[:len(original):len(original)], additional...))
+	case MergeStrategyMergeByKey:
+		ret, err = m.mergeListByKey(path, p.key, original, additional)
+	default:
+		switch {
+		case hasListMergeKey(additional, m.opts.ListMergeKey):
+			ret, err = m.mergeListByKey(path, m.opts.ListMergeKey, original, additional)
+		case m.opts.AppendList:
+			ret = append(original, additional...)
+		default:
+			ret = additional
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m.opts.UniqueInListItems {
+		ret = UniqueList(ret)
+	}
+
+	return ret, nil
+}
+
+func (m *merger) mergeMap(path []mergePathSegment, original, additional map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(original))
+	for k, v := range original {
+		out[k] = v
+	}
+
+	for k, v := range additional {
+		ov, ok := out[k]
+		if !ok {
+			out[k] = v
+			continue
+		}
+
+		merged, err := m.mergeValue(mergePathSegment{key: k}.child(path), ov, v)
+		if err != nil {
+			return nil, err
+		}
+
+		out[k] = merged
+	}
+
+	return out, nil
+}
+
+// mergeListByKey merges additional into a copy of original, objects with the same value
+// of key are merged, others are appended
+func (m *merger) mergeListByKey(path []mergePathSegment, key string, original, additional []any) ([]any, error) {
+	out := make([]any, len(original), len(original)+len(additional))
+	copy(out, original)
+
+	itemPath := mergePathSegment{item: true}.child(path)
+
+	// index of objects in out by the value of the merge key
+	index := make(map[any]int)
+	addIndex := func(i int) {
+		if kv, ok := listMergeKeyValue(out[i], key); ok {
+			if _, exists := index[kv]; !exists {
+				index[kv] = i
+			}
+		}
+	}
+
+	for i := range out {
+		addIndex(i)
+	}
+
+	for _, item := range additional {
+		if kv, ok := listMergeKeyValue(item, key); ok {
+			if i, exists := index[kv]; exists {
+				merged, err := m.mergeMap(itemPath, out[i].(map[string]any), item.(map[string]any))
+				if err != nil {
+					return nil, fmt.Errorf("merge list item %s=%v: %w", key, kv, err)
+				}
+
+				out[i] = merged
+				continue
+			}
+		}
+
+		out = append(out, item)
+		addIndex(len(out) - 1)
+	}
+
+	return out, nil
+}
+
+// listMergeKeyValue returns the value of key in item when item is an object having
+// comparable value of key
+func listMergeKeyValue(item any, key string) (any, bool) {
+	obj, ok := item.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	kv, ok := obj[key]
+	if !ok || kv == nil || !reflect.TypeOf(kv).Comparable() {
+		return nil, false
+	}
+
+	return kv, true
+}
+
+// hasListMergeKey checks whether there is any object in list having key
+func hasListMergeKey(list []any, key string) bool {
+	if len(key) == 0 {
+		return false
+	}
+
+	for _, item := range list {
+		if m, ok := item.(map[string]any); ok {
+			if _, ok = m[key]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// valueKind returns the kind of normalized value for type comparison
+func valueKind(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "map"
+	case []any:
+		return "list"
+	case nil:
+		return "null"
+	default:
+		return "scalar"
+	}
+}
+
+// ScalarMergeStrategy is the strategy of merging scalar values, set it in PatchSpec.ScalarMerge
+//
+// merge values of null are skipped by PatchSpec as there is no value to merge, the same as
+// merging maps and lists, e.g. merging `b` and `~` into `a` with `override` results in `b`
+type ScalarMergeStrategy string
+
+// nolint:revive
+const (
+	// ScalarMergeConcat concatenates values as strings, with separator in between
+	ScalarMergeConcat ScalarMergeStrategy = "concat"
+
+	// ScalarMergeSum adds numbers
+	ScalarMergeSum ScalarMergeStrategy = "sum"
+
+	// ScalarMergeMax keeps the largest number
+	ScalarMergeMax ScalarMergeStrategy = "max"
+
+	// ScalarMergeMin keeps the smallest number
+	ScalarMergeMin ScalarMergeStrategy = "min"
+
+	// ScalarMergeOverride keeps the last value, null merge values are skipped by PatchSpec
+	ScalarMergeOverride ScalarMergeStrategy = "override"
+
+	// ScalarMergeAnd is logical and of bools
+	ScalarMergeAnd ScalarMergeStrategy = "and"
+
+	// ScalarMergeOr is logical or of bools
+	ScalarMergeOr ScalarMergeStrategy = "or"
+)
+
+// validateScalarMergeStrategy returns an error when strategy is set but not supported
+func validateScalarMergeStrategy(strategy ScalarMergeStrategy) error {
+	switch strategy {
+	case "", ScalarMergeConcat, ScalarMergeSum, ScalarMergeMax, ScalarMergeMin,
+		ScalarMergeOverride, ScalarMergeAnd, ScalarMergeOr:
+		return nil
+	default:
+		return fmt.Errorf("unknown scalar merge strategy %q", strategy)
+	}
+}
+
+// mergeScalar merges scalar value b into a
+func mergeScalar(strategy ScalarMergeStrategy, sep string, a, b any) (any, error) {
+	if valueKind(b) != "scalar" {
+		return nil, fmt.Errorf("unexpected non scalar value of merge, got %T", b)
+	}
+
+	switch strategy {
+	case ScalarMergeOverride:
+		return b, nil
+	case ScalarMergeConcat:
+		return fmt.Sprint(a) + sep + fmt.Sprint(b), nil
+	case ScalarMergeSum, ScalarMergeMax, ScalarMergeMin:
+		ai, af, aIsInt, ok := toNumber(a)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non number value (%T) for %q", a, strategy)
+		}
+
+		bi, bf, bIsInt, ok := toNumber(b)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non number value of merge (%T) for %q", b, strategy)
+		}
+
+		if aIsInt && bIsInt {
+			return mergeNumber(strategy, ai, bi), nil
+		}
+
+		return mergeNumber(strategy, af, bf), nil
+	case ScalarMergeAnd, ScalarMergeOr:
+		ab, ok := a.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non bool value (%T) for %q", a, strategy)
+		}
+
+		bb, ok := b.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected non bool value of merge (%T) for %q", b, strategy)
+		}
+
+		if strategy == ScalarMergeAnd {
+			return ab && bb, nil
+		}
+
+		return ab || bb, nil
+	default:
+		return nil, fmt.Errorf("unknown scalar merge strategy %q", strategy)
+	}
+}
+
+func mergeNumber[T int | float64](strategy ScalarMergeStrategy, a, b T) T {
+	switch {
+	case strategy == ScalarMergeSum:
+		return a + b
+	case strategy == ScalarMergeMax && b > a,
+		strategy == ScalarMergeMin && b < a:
+		return b
+	default:
+		return a
+	}
+}
+
+// toNumber converts decoded yaml number to int and float64
+func toNumber(v any) (i int, f float64, isInt, ok bool) {
+	switch t := v.(type) {
+	case int:
+		return t, float64(t), true, true
+	case int64:
+		return int(t), float64(t), true, true
+	case uint64:
+		// too large for int
+		return 0, float64(t), false, true
+	case float64:
+		return int(t), t, false, true
+	default:
+		return 0, 0, false, false
+	}
+}
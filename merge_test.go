@@ -0,0 +1,195 @@
+package rs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeMapWithOptions(t *testing.T) {
+	for _, test := range []struct {
+		name string
+
+		original   string
+		additional string
+		opts       MergeOptions
+
+		expected string
+		err      string
+	}{
+		{
+			name:       "Default",
+			original:   `{ a: [a], b: { c: [c] } }`,
+			additional: `{ a: [b], b: { c: [d], d: d } }`,
+			expected:   `{ a: [b], b: { c: [d], d: d } }`,
+		},
+		{
+			name:       "Per Path",
+			original:   `{ env: [a], args: [a], pre: [a], uniq: [a, b], items: [{ env: [a] }] }`,
+			additional: `{ env: [b], args: [b], pre: [b], uniq: [b, c], items: [{ env: [b] }] }`,
+			opts: MergeOptions{
+				AppendList: true,
+				Strategies: map[string]MergeStrategy{
+					"$.env":            MergeStrategyAppend,
+					"args":             MergeStrategyReplace,
+					"$['pre']":         MergeStrategyPrepend,
+					"$.uniq":           MergeStrategyUniqueAppend,
+					"$.items":          MergeStrategyReplace,
+					"$.items[*].env":   MergeStrategyAppend,
+					"$.not.existing.*": MergeStrategyAppend,
+				},
+			},
+			expected: `{ env: [a, b], args: [b], pre: [b, a], uniq: [a, b, c], items: [{ env: [b] }] }`,
+		},
+		{
+			name:       "Merge By Key",
+			original:   `{ spec: { env: [{ name: a, value: "1", list: [x] }] } }`,
+			additional: `{ spec: { env: [{ name: a, value: "2", list: [y] }, { name: b }] } }`,
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{
+					"$.*.env":            "merge-by-key(name)",
+					"$.spec.env[*].list": MergeStrategyAppend,
+				},
+			},
+			expected: `{ spec: { env: [{ name: a, value: "2", list: [x, y] }, { name: b }] } }`,
+		},
+		{
+			name:       "Override On Type Mismatch",
+			original:   `{ a: { b: c }, d: [e] }`,
+			additional: `{ a: [b], d: { e: f } }`,
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{
+					"$.*": MergeStrategyOverrideOnTypeMismatch,
+				},
+			},
+			expected: `{ a: [b], d: { e: f } }`,
+		},
+		{
+			name:       "Type Mismatch",
+			original:   `{ a: { b: c } }`,
+			additional: `{ a: [b] }`,
+			err:        "unexpected non list data at $.a",
+		},
+		{
+			name:       "Error On Conflict",
+			original:   `{ a: { b: c, d: e } }`,
+			additional: `{ a: { b: c, d: f } }`,
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{
+					"$.a.*": MergeStrategyErrorOnConflict,
+				},
+			},
+			err: "conflict values at $.a.d",
+		},
+		{
+			name:       "No Conflict",
+			original:   `{ a: { b: c } }`,
+			additional: `{ a: { b: c, d: f } }`,
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{
+					"$.a.*": MergeStrategyErrorOnConflict,
+				},
+			},
+			expected: `{ a: { b: c, d: f } }`,
+		},
+		{
+			name:       "List Strategy Not List",
+			original:   `{ a: a }`,
+			additional: `{ a: b }`,
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{"$.a": MergeStrategyAppend},
+			},
+			err: `merge strategy "append" at $.a requires lists`,
+		},
+		{
+			name: "Unknown Strategy",
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{"$.a": "foo"},
+			},
+			err: `unknown merge strategy "foo"`,
+		},
+		{
+			name: "No Merge Key",
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{"$.a": MergeStrategyMergeByKey},
+			},
+			err: "no key for merge strategy",
+		},
+		{
+			name: "Invalid Path",
+			opts: MergeOptions{
+				Strategies: map[string]MergeStrategy{"$.a[0]": MergeStrategyAppend},
+			},
+			err: "unsupported list index",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var original, additional, expected map[string]any
+			assert.NoError(t, yaml.Unmarshal([]byte(test.original), &original))
+			assert.NoError(t, yaml.Unmarshal([]byte(test.additional), &additional))
+
+			ret, err := MergeMapWithOptions(original, additional, &test.opts)
+			if len(test.err) != 0 {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NoError(t, yaml.Unmarshal([]byte(test.expected), &expected))
+			assert.EqualValues(t, expected, ret)
+		})
+	}
+}
+
+func TestParseMergePath(t *testing.T) {
+	for path, expected := range map[string][]mergePathSegment{
+		"$":             nil,
+		"$.a":           {{key: "a"}},
+		"a.b":           {{key: "a"}, {key: "b"}},
+		"$.a[*].b":      {{key: "a"}, {item: true}, {key: "b"}},
+		`$['a.b']["c"]`: {{key: "a.b"}, {key: "c"}},
+	} {
+		segments, _, err := parseMergePath(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, segments, path)
+	}
+
+	for _, path := range []string{"$..a", "$.a[0]", "$['a", "$.a[*]b"} {
+		_, _, err := parseMergePath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestMergeScalar(t *testing.T) {
+	for _, test := range []struct {
+		strategy ScalarMergeStrategy
+		a, b     any
+
+		expected any
+		err      string
+	}{
+		{strategy: ScalarMergeConcat, a: "a", b: 1, expected: "a1"},
+		{strategy: ScalarMergeSum, a: 1, b: 2, expected: 3},
+		{strategy: ScalarMergeSum, a: 1, b: 0.5, expected: 1.5},
+		{strategy: ScalarMergeMax, a: 1.5, b: 1, expected: 1.5},
+		{strategy: ScalarMergeMin, a: 2, b: -1, expected: -1},
+		{strategy: ScalarMergeOverride, a: 1, b: "a", expected: "a"},
+		{strategy: ScalarMergeAnd, a: true, b: true, expected: true},
+		{strategy: ScalarMergeOr, a: false, b: false, expected: false},
+
+		{strategy: ScalarMergeSum, a: "1", b: 2, err: "non number value"},
+		{strategy: ScalarMergeOr, a: false, b: 1, err: "non bool value of merge"},
+		{strategy: ScalarMergeConcat, a: "a", b: []any{"b"}, err: "non scalar value of merge"},
+		{strategy: "foo", a: 1, b: 2, err: `unknown scalar merge strategy "foo"`},
+	} {
+		ret, err := mergeScalar(test.strategy, "", test.a, test.b)
+		if len(test.err) != 0 {
+			assert.ErrorContains(t, err, test.err)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, ret)
+	}
+}
@@ -1,12 +1,13 @@
 package rs
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
 
-	jsonpatch "github.com/evanphx/json-patch/v5"
-	"github.com/itchyny/gojq"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,6 +24,10 @@ type MergeSource struct {
 
 	// Select some data from the source
 	Select string `yaml:"select,omitempty"`
+
+	// Vars are jq variables available in Select as `$<name>`, in addition to (and
+	// overriding) Vars of the PatchSpec
+	Vars map[string]any `yaml:"vars,omitempty" rs:"keys"`
 }
 
 // PatchSpec is the input definition for renderers with a patching suffix
@@ -50,17 +55,34 @@ type PatchSpec struct {
 	// this action happens first
 	Merge []MergeSource `yaml:"merge,omitempty"`
 
-	// Patch Value using standard rfc6902 json-patch
+	// MergePatch applies rfc7386 json merge patch documents to Value in order,
+	// (`null` removes the key, lists are replaced)
 	//
 	// this action happens after merge
+	MergePatch []MergeSource `yaml:"merge_patch,omitempty"`
+
+	// Patch Value using standard rfc6902 json-patch
+	//
+	// this action happens after merge and merge patch
 	Patch []JSONPatchSpec `yaml:"patch"`
 
 	// Select part of the data as final result
 	//
-	// this action happens after merge and patch
-	// TODO: support jq variables
+	// this action happens after merge, merge patch and patch
 	Select string `yaml:"select"`
 
+	// Vars are jq variables available as `$<name>` in select of the patch spec and
+	// selects of its merge and patch items, they are also available as `$ARGS.named`
+	//
+	// each variable can have its own rendering suffix
+	//
+	// 		foo@!:
+	// 		  value@http: https://example.com/items.json
+	// 		  vars:
+	// 		    name@env: ${ITEM_NAME}
+	// 		  select: .[] | select(.name == $name)
+	Vars map[string]any `yaml:"vars,omitempty" rs:"keys"`
+
 	// TODO: give following options proper names
 
 	// Unique to make sure elements in the sequence is unique
@@ -68,18 +90,103 @@ type PatchSpec struct {
 	// only effective when Value is yaml sequence
 	Unique bool `yaml:"unique"`
 
+	// UniqueBy is a jq query generating the key of each element in the sequence, elements
+	// with duplicate keys are removed after merge, first occurrences are kept
+	// (e.g. `.name` to dedupe objects by name)
+	//
+	// only effective when Value is yaml sequence
+	UniqueBy string `yaml:"unique_by"`
+
 	// MapListItemUnique to ensure items are unique in all merged lists respectively
 	// lists with no merge data input are untouched
 	MapListItemUnique bool `yaml:"map_list_item_unique"`
 
 	// MapListAppend to append lists instead of replacing existing list
 	MapListAppend bool `yaml:"map_list_append"`
+
+	// ListMergeKey enables strategic merge for lists of objects (including Value and
+	// lists in maps), objects with the same value of this key are merged, others are
+	// appended (e.g. merge `env` lists by `name`)
+	//
+	// lists in maps without any object having this key are merged as usual
+	ListMergeKey string `yaml:"list_merge_key"`
+
+	// MergeStrategies sets merge strategies by path of values in JSONPath style, overriding
+	// options above, see MergeStrategy for supported strategies
+	//
+	// 		foo@!:
+	// 		  value: { env: [a], args: [b] }
+	// 		  merge:
+	// 		  - value: { env: [c], args: [d] }
+	// 		  merge_strategy:
+	// 		    $.env: append
+	// 		    $.args: replace
+	//
+	// then the resolved value of foo will be `{ env: [a, c], args: [d] }`
+	MergeStrategies map[string]MergeStrategy `yaml:"merge_strategy"`
+
+	// ScalarMerge is the strategy to merge scalar Value with merge sources, see
+	// ScalarMergeStrategy for supported strategies
+	//
+	// 		foo@!:
+	// 		  value: --verbose
+	// 		  merge:
+	// 		  - value@env: ${EXTRA_FLAGS}
+	// 		  scalar_merge: concat
+	// 		  scalar_merge_separator: " "
+	//
+	// defaults to empty, merging scalar values is an error
+	ScalarMerge ScalarMergeStrategy `yaml:"scalar_merge"`
+
+	// ScalarMergeSeparator is inserted between strings when ScalarMerge is `concat`
+	ScalarMergeSeparator string `yaml:"scalar_merge_separator"`
 }
 
-func runJQ(query string, data any) (any, error) {
-	q, err := gojq.Parse(query)
+// JQFunction is a custom jq function for jq queries, set it in Options.JQFunctions
+//
+// see gojq.WithFunction for the requirements of Fn
+type JQFunction struct {
+	// Name of the function in jq queries
+	Name string
+
+	// MinArity and MaxArity are the range of argument count
+	MinArity, MaxArity int
+
+	// Fn is called with the input value and arguments of the function
+	Fn func(v any, args []any) any
+}
+
+// runJQ runs jq query over data with vars bound as `$<name>` (and `$ARGS.named`),
+// results are collected as a list when there are more than one
+//
+// jq functions in opts (can be nil) are available in the query, compiled queries are
+// cached, see SetJQCacheSize
+func runJQ(ctx context.Context, query string, data any, vars map[string]any, opts *Options) (any, error) {
+	names := make([]string, 0, len(vars)+1)
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]any, 0, len(names)+1)
+	for i, name := range names {
+		values = append(values, vars[name])
+		names[i] = "$" + name
+	}
+
+	if _, ok := vars["ARGS"]; !ok {
+		named := make(map[string]any, len(vars))
+		for k, v := range vars {
+			named[k] = v
+		}
+
+		names = append(names, "$ARGS")
+		values = append(values, map[string]any{"positional": []any{}, "named": named})
+	}
+
+	code, err := compileJQ(query, names, opts)
 	if err != nil {
-		return nil, fmt.Errorf("invalid jq query: %w", err)
+		return nil, err
 	}
 
 	var (
@@ -88,7 +195,7 @@ func runJQ(query string, data any) (any, error) {
 		ok  bool
 	)
 
-	iter := q.Run(data)
+	iter := code.RunWithContext(ctx, data, values...)
 	for {
 		var v any
 		v, ok = iter.Next()
@@ -115,34 +222,120 @@ func runJQ(query string, data any) (any, error) {
 	return ret, nil
 }
 
-func (s *PatchSpec) merge(rc RenderingHandler, valueData any) (any, error) {
-	mergeSrc := make([]any, len(s.Merge))
-	for i, m := range s.Merge {
-		v, err := handleOptionalRenderingSuffixResolving(m.Value, m.Resolve, rc)
+// newMergeError creates an *Error located at value of the i-th merge source
+func (s *PatchSpec) newMergeError(i int, err error) error {
+	return newError(typeStruct_PatchSpec, fmt.Sprintf("merge[%d]", i), s.Merge[i].Value, "", err)
+}
+
+// runJQ runs jq query with vars of the patch spec and extra vars, using jq functions
+// in Options of the patch spec
+func (s *PatchSpec) runJQ(ctx context.Context, query string, data any, extraVars map[string]any) (any, error) {
+	vars := s.Vars
+	if len(extraVars) != 0 {
+		vars = make(map[string]any, len(s.Vars)+len(extraVars))
+		for k, v := range s.Vars {
+			vars[k] = v
+		}
+
+		for k, v := range extraVars {
+			vars[k] = v
+		}
+	}
+
+	return runJQ(ctx, query, data, vars, s._opts)
+}
+
+// mergeOptions returns options for merging maps and lists
+func (s *PatchSpec) mergeOptions() *MergeOptions {
+	return &MergeOptions{
+		AppendList:        s.MapListAppend,
+		UniqueInListItems: s.MapListItemUnique,
+		ListMergeKey:      s.ListMergeKey,
+		Strategies:        s.MergeStrategies,
+	}
+}
+
+// resolveMergeSource resolves value of m with select applied
+func (s *PatchSpec) resolveMergeSource(ctx context.Context, rc RenderingHandler, m *MergeSource) (any, error) {
+	v, err := handleOptionalRenderingSuffixResolving(ctx, m.Value, m.Resolve, rc, s._opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Select) != 0 {
+		v, err = s.runJQ(ctx, m.Select, v, m.Vars)
 		if err != nil {
-			return nil, err
+			return nil, wrapErrorf(err, "run select")
 		}
+	}
 
-		if len(m.Select) != 0 {
-			v, err = runJQ(m.Select, v)
-			if err != nil {
-				return nil, fmt.Errorf(
-					"run select over merge#%d: %w",
-					i, err,
-				)
-			}
+	return v, nil
+}
+
+// mergePatch applies merge patch documents to data
+func (s *PatchSpec) mergePatch(ctx context.Context, rc RenderingHandler, data any) (any, error) {
+	for i := range s.MergePatch {
+		m := &s.MergePatch[i]
+		patch, err := s.resolveMergeSource(ctx, rc, m)
+		if err != nil {
+			return nil, newError(typeStruct_PatchSpec, fmt.Sprintf("merge_patch[%d]", i), m.Value, "", err)
+		}
+
+		data = JSONMergePatch(data, patch)
+	}
+
+	return data, nil
+}
+
+func (s *PatchSpec) merge(ctx context.Context, rc RenderingHandler, valueData any) (any, error) {
+	// validate strategies before rendering merge sources, even if they are not used
+	m, err := newMerger(s.mergeOptions())
+	if err != nil {
+		return nil, newError(typeStruct_PatchSpec, "merge_strategy", nil, "", err)
+	}
+
+	err = validateScalarMergeStrategy(s.ScalarMerge)
+	if err != nil {
+		return nil, newError(typeStruct_PatchSpec, "scalar_merge", nil, "", err)
+	}
+
+	mergeSrc := make([]any, len(s.Merge))
+	for i := range s.Merge {
+		var v any
+		v, err = s.resolveMergeSource(ctx, rc, &s.Merge[i])
+		if err != nil {
+			return nil, s.newMergeError(i, err)
 		}
 
 		mergeSrc[i] = v
 	}
 
+	// offset is the index of mergeSrc[0] in s.Merge
+	offset := 0
+
 doMerge:
 	switch dt := valueData.(type) {
 	case []any:
-		for _, merge := range mergeSrc {
+		for i, merge := range mergeSrc {
 			switch mt := merge.(type) {
 			case []any:
-				dt = append(dt, mt...)
+				switch {
+				case m.strategyFor(nil) != nil:
+					var merged any
+					merged, err = m.mergeValue(nil, dt, mt)
+					if err != nil {
+						return nil, s.newMergeError(offset+i, fmt.Errorf("merge list value: %w", err))
+					}
+
+					dt = merged.([]any)
+				case len(s.ListMergeKey) != 0:
+					dt, err = m.mergeListByKey(nil, s.ListMergeKey, dt, mt)
+					if err != nil {
+						return nil, s.newMergeError(offset+i, fmt.Errorf("merge list value: %w", err))
+					}
+				default:
+					dt = append(dt, mt...)
+				}
 
 				if s.Unique {
 					dt = UniqueList(dt)
@@ -151,25 +344,40 @@ doMerge:
 				// no value to merge, skip
 			default:
 				// invalid type, not able to merge
-				return nil, fmt.Errorf("unexpected non list value of merge, got %T", mt)
+				return nil, s.newMergeError(offset+i, fmt.Errorf(
+					"unexpected non list value of merge, got %T", mt,
+				))
+			}
+		}
+
+		if len(s.UniqueBy) != 0 {
+			dt, err = uniqueListBy(dt, func(item any) (any, error) {
+				return s.runJQ(ctx, s.UniqueBy, item, nil)
+			})
+			if err != nil {
+				return nil, newError(typeStruct_PatchSpec, "unique_by", nil, "", err)
 			}
 		}
 
 		return dt, nil
 	case map[string]any:
-		var err error
-		for _, merge := range mergeSrc {
+		for i, merge := range mergeSrc {
 			switch mt := merge.(type) {
 			case map[string]any:
-				dt, err = MergeMap(dt, mt, s.MapListAppend, s.MapListItemUnique)
+				var merged any
+				merged, err = m.mergeValue(nil, dt, mt)
 				if err != nil {
-					return nil, fmt.Errorf("merge map value: %w", err)
+					return nil, s.newMergeError(offset+i, fmt.Errorf("merge map value: %w", err))
 				}
+
+				dt = merged.(map[string]any)
 			case nil:
 				// no value to merge, skip
 			default:
 				// invalid type, not able to merge
-				return nil, fmt.Errorf("unexpected non map value of merge, got %T", mt)
+				return nil, s.newMergeError(offset+i, fmt.Errorf(
+					"unexpected non map value of merge, got %T", mt,
+				))
 			}
 		}
 
@@ -183,125 +391,163 @@ doMerge:
 		default:
 			valueData = mergeSrc[0]
 			mergeSrc = mergeSrc[1:]
+			offset++
 			goto doMerge
 		}
 	default:
-		// TODO: merge scalar data, how?
-		if len(mergeSrc) != 0 {
-			return nil, fmt.Errorf(
-				"mergering scalar type value (%T) is not supported",
-				valueData,
-			)
+		if len(s.ScalarMerge) == 0 {
+			if len(mergeSrc) != 0 {
+				return nil, s.newMergeError(offset, fmt.Errorf(
+					"mergering scalar type value (%T) without scalar_merge is not supported",
+					valueData,
+				))
+			}
+
+			// no merge source
+			return valueData, nil
+		}
+
+		for i, merge := range mergeSrc {
+			if merge == nil {
+				// no value to merge, skip
+				continue
+			}
+
+			valueData, err = mergeScalar(s.ScalarMerge, s.ScalarMergeSeparator, valueData, merge)
+			if err != nil {
+				return nil, s.newMergeError(offset+i, err)
+			}
 		}
 
-		// no merge source
 		return valueData, nil
 	}
 }
 
 // Apply Merge and Patch to Value, Unique is ensured if set to true
 func (s *PatchSpec) Apply(rc RenderingHandler) (_ any, err error) {
-	valueData, err := handleOptionalRenderingSuffixResolving(s.Value, s.Resolve, rc)
+	return s.ApplyContext(context.Background(), rc)
+}
+
+// ApplyContext is Apply with a context used for rendering
+func (s *PatchSpec) ApplyContext(ctx context.Context, rc RenderingHandler) (_ any, err error) {
+	ret, err := s.apply(ctx, rc)
+	if err != nil {
+		return
+	}
+
+	n, ok := ret.(*yaml.Node)
+	if !ok {
+		return ret, nil
+	}
+
+	ret = nil
+	err = n.Decode(&ret)
+	if err != nil {
+		err = fmt.Errorf("decode patched value: %w", err)
+		return
+	}
+
+	return ret, nil
+}
+
+// apply is ApplyContext, but the result is kept as *yaml.Node when patched with no select
+func (s *PatchSpec) apply(ctx context.Context, rc RenderingHandler) (_ any, err error) {
+	valueData, err := handleOptionalRenderingSuffixResolving(ctx, s.Value, s.Resolve, rc, s._opts)
 	if err != nil {
+		err = newError(typeStruct_PatchSpec, "value", s.Value, "", err)
 		return
 	}
 
-	data, err := s.merge(rc, valueData)
+	data, err := s.merge(ctx, rc, valueData)
 	if err != nil {
 		return
 	}
 
-	type resolvedJSONPatchSpec struct {
-		Operation string `json:"op"`
-		Path      string `json:"path"`
-		Value     any    `json:"value,omitempty"`
+	data, err = s.mergePatch(ctx, rc, data)
+	if err != nil {
+		return
+	}
+
+	if len(s.Patch) == 0 {
+		if len(s.Select) != 0 {
+			data, err = s.runJQ(ctx, s.Select, data, nil)
+			if err != nil {
+				err = newError(typeStruct_PatchSpec, "select", nil, "", err)
+				return
+			}
+		}
+
+		return data, nil
 	}
 
 	// apply select action to patches
-	patchSrc := make([]resolvedJSONPatchSpec, len(s.Patch))
+	ops := make([]JSONPatchOperation, len(s.Patch))
 	for i, p := range s.Patch {
 		var v any
 		v, err = handleOptionalRenderingSuffixResolving(
-			p.Value, p.Resolve, rc,
+			ctx, p.Value, p.Resolve, rc, s._opts,
 		)
 		if err != nil {
+			err = newError(typeStruct_PatchSpec, fmt.Sprintf("patch[%d]", i), p.Value, "", err)
 			return
 		}
 
-		patchSrc[i] = resolvedJSONPatchSpec{
-			Path:      p.Path,
-			Operation: p.Operation,
-			Value:     v,
-		}
-
 		if len(p.Select) != 0 {
-			patchSrc[i].Value, err = runJQ(p.Select, patchSrc[i].Value)
+			v, err = s.runJQ(ctx, p.Select, v, p.Vars)
 			if err != nil {
-				return nil, fmt.Errorf(
+				return nil, newError(typeStruct_PatchSpec, fmt.Sprintf("patch[%d]", i), p.Value, "", fmt.Errorf(
 					"run select over patch#%d: %w",
 					i, err,
-				)
+				))
 			}
 		}
-	}
 
-	if len(patchSrc) == 0 {
-		if len(s.Select) != 0 {
-			data, err = runJQ(s.Select, data)
-			if err != nil {
-				return
-			}
+		ops[i] = JSONPatchOperation{
+			Op:   p.Operation,
+			Path: p.Path,
+			From: p.From,
 		}
 
-		return data, nil
-	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return
+		ops[i].Value, err = anyToYamlNode(v)
+		if err != nil {
+			err = newError(typeStruct_PatchSpec, fmt.Sprintf("patch[%d]", i), p.Value, "", err)
+			return
+		}
 	}
 
-	patchData, err := json.Marshal(patchSrc)
+	doc, err := anyToYamlNode(data)
 	if err != nil {
+		err = newError(typeStruct_PatchSpec, "value", s.Value, "", err)
 		return
 	}
 
-	patch, err := jsonpatch.DecodePatch(patchData)
+	doc, err = ApplyJSONPatch(doc, ops)
 	if err != nil {
+		err = newError(typeStruct_PatchSpec, "patch", nil, "", err)
 		return
 	}
 
-	options := jsonpatch.ApplyOptions{
-		SupportNegativeIndices:   true,
-		EnsurePathExistsOnAdd:    false,
-		AccumulatedCopySizeLimit: 0,
-		AllowMissingPathOnRemove: true,
-	}
-
-	patchedDoc, err := patch.ApplyIndentWithOptions(jsonData, "", &options)
-	if err != nil {
-		return
+	if len(s.Select) == 0 {
+		return doc, nil
 	}
 
 	var ret any
-	err = json.Unmarshal(patchedDoc, &ret)
+	err = doc.Decode(&ret)
 	if err != nil {
-		err = fmt.Errorf("unmarshal patched value: %w", err)
+		err = fmt.Errorf("decode patched value: %w", err)
 		return
 	}
 
-	if len(s.Select) == 0 {
-		return ret, nil
-	}
-
-	ret, err = runJQ(s.Select, ret)
+	ret, err = s.runJQ(ctx, s.Select, ret, nil)
 	if err != nil {
-		return nil, err
+		return nil, newError(typeStruct_PatchSpec, "select", nil, "", err)
 	}
 
 	return ret, nil
 }
 
+// MergeMap merges additional into a copy of original recursively, lists are replaced
+// unless appendList is set
 func MergeMap(
 	original, additional map[string]any,
 
@@ -309,78 +555,199 @@ func MergeMap(
 	appendList bool,
 	uniqueInListItems bool,
 ) (map[string]any, error) {
-	out := make(map[string]any, len(original))
-	for k, v := range original {
-		out[k] = v
-	}
-
-	var err error
-	for k, v := range additional {
-		switch newVal := v.(type) {
-		case map[string]any:
-			if originalVal, ok := out[k]; ok {
-				if orignalMap, ok := originalVal.(map[string]any); ok {
-					out[k], err = MergeMap(orignalMap, newVal, appendList, uniqueInListItems)
-					if err != nil {
-						return nil, err
-					}
+	return MergeMapWithOptions(original, additional, &MergeOptions{
+		AppendList:        appendList,
+		UniqueInListItems: uniqueInListItems,
+	})
+}
 
-					continue
-				} else {
-					return nil, fmt.Errorf("unexpected non map data %q: %v", k, orignalMap)
-				}
-			} else {
-				out[k] = newVal
-			}
-		case []any:
-			if originalVal, ok := out[k]; ok {
-				if originalList, ok := originalVal.([]any); ok {
-					if appendList {
-						originalList = append(originalList, newVal...)
-					} else {
-						originalList = newVal
-					}
+// MergeListByKey merges additional into a copy of original as strategic merge, objects
+// with the same value of key are merged using MergeMap, others are appended
+func MergeListByKey(original, additional []any, key string) ([]any, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty list merge key")
+	}
 
-					if uniqueInListItems {
-						originalList = UniqueList(originalList)
-					}
+	m := &merger{opts: MergeOptions{ListMergeKey: key}}
+	return m.mergeListByKey(nil, key, original, additional)
+}
 
-					out[k] = originalList
+// JSONMergePatch applies rfc7386 json merge patch to target, target is not modified
+func JSONMergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
 
-					continue
-				} else {
-					return nil, fmt.Errorf("unexpected non list data %q: %v", k, originalList)
-				}
-			} else {
-				out[k] = newVal
-			}
-		default:
-			out[k] = newVal
+	t, ok := target.(map[string]any)
+	out := make(map[string]any, len(t)+len(p))
+	if ok {
+		for k, v := range t {
+			out[k] = v
 		}
 	}
 
-	return out, nil
+	for k, v := range p {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+
+		out[k] = JSONMergePatch(out[k], v)
+	}
+
+	return out
 }
 
+// UniqueList returns items of dt with duplicates removed, first occurrences are kept in order
 func UniqueList(dt []any) []any {
-	var ret []any
-	dupAt := make(map[int]struct{})
-	for i := range dt {
-		_, isDup := dupAt[i]
-		if isDup {
-			continue
+	ret, _ := uniqueListBy(dt, nil)
+	return ret
+}
+
+// uniqueListBy removes items of dt with duplicate keys, first occurrences are kept in order,
+// the key of the item is the item itself when keyOf is nil
+//
+// keys are compared by their canonical encoding, and fallback to reflect.DeepEqual
+// when not encodable
+func uniqueListBy(dt []any, keyOf func(item any) (any, error)) ([]any, error) {
+	var (
+		ret []any
+
+		seen = make(map[string]struct{}, len(dt))
+		// keys not encodable
+		others []any
+
+		buf []byte
+	)
+
+	for _, item := range dt {
+		key := item
+		if keyOf != nil {
+			var err error
+			key, err = keyOf(item)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		for j := i; j < len(dt); j++ {
-			if reflect.DeepEqual(dt[i], dt[j]) {
-				dupAt[j] = struct{}{}
+		var ok bool
+		buf, ok = appendCanonicalValue(buf[:0], key)
+		if ok {
+			if _, isDup := seen[string(buf)]; isDup {
+				continue
 			}
+
+			seen[string(buf)] = struct{}{}
+		} else {
+			isDup := false
+			for _, o := range others {
+				if reflect.DeepEqual(o, key) {
+					isDup = true
+					break
+				}
+			}
+
+			if isDup {
+				continue
+			}
+
+			others = append(others, key)
 		}
 
-		ret = append(ret, dt[i])
+		ret = append(ret, item)
 	}
 
-	return ret
+	return ret, nil
+}
+
+// appendCanonicalValue appends the canonical encoding of normalized value v to buf,
+// two values have the same encoding only when they are deeply equal
+//
+// ok is false when v is not encodable (e.g. NaN or values of unknown types)
+func appendCanonicalValue(buf []byte, v any) (_ []byte, ok bool) {
+	switch t := v.(type) {
+	case nil:
+		return append(buf, 'z'), true
+	case bool:
+		if t {
+			return append(buf, 'T'), true
+		}
+
+		return append(buf, 'F'), true
+	case string:
+		buf = append(buf, 's')
+		buf = strconv.AppendInt(buf, int64(len(t)), 10)
+		buf = append(buf, ':')
+		return append(buf, t...), true
+	case int:
+		buf = append(buf, 'i')
+		buf = strconv.AppendInt(buf, int64(t), 10)
+		return append(buf, ';'), true
+	case int64:
+		buf = append(buf, 'I')
+		buf = strconv.AppendInt(buf, t, 10)
+		return append(buf, ';'), true
+	case uint64:
+		buf = append(buf, 'u')
+		buf = strconv.AppendUint(buf, t, 10)
+		return append(buf, ';'), true
+	case float64:
+		if math.IsNaN(t) {
+			// NaN is not equal to itself
+			return buf, false
+		}
+
+		if t == 0 {
+			// -0 equals 0
+			t = 0
+		}
+
+		buf = append(buf, 'f')
+		buf = strconv.AppendFloat(buf, t, 'g', -1, 64)
+		return append(buf, ';'), true
+	case []any:
+		if t == nil {
+			return append(buf, 'L'), true
+		}
+
+		buf = append(buf, 'l')
+		buf = strconv.AppendInt(buf, int64(len(t)), 10)
+		buf = append(buf, '[')
+		for _, item := range t {
+			buf, ok = appendCanonicalValue(buf, item)
+			if !ok {
+				return buf, false
+			}
+		}
+
+		return append(buf, ']'), true
+	case map[string]any:
+		if t == nil {
+			return append(buf, 'M'), true
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		buf = append(buf, 'm')
+		buf = strconv.AppendInt(buf, int64(len(t)), 10)
+		buf = append(buf, '{')
+		for _, k := range keys {
+			buf, _ = appendCanonicalValue(buf, k)
+			buf, ok = appendCanonicalValue(buf, t[k])
+			if !ok {
+				return buf, false
+			}
+		}
+
+		return append(buf, '}'), true
+	default:
+		return buf, false
+	}
 }
 
 // JSONPatchSpec per rfc6902
@@ -391,6 +758,9 @@ type JSONPatchSpec struct {
 
 	Path string `yaml:"path"`
 
+	// From is the source path of move and copy operations
+	From string `yaml:"from,omitempty"`
+
 	Value *yaml.Node `yaml:"value,omitempty"`
 
 	// Resolve rendering suffix in value before being applied
@@ -402,4 +772,8 @@ type JSONPatchSpec struct {
 	//
 	// this action happens before patching
 	Select string `yaml:"select"`
+
+	// Vars are jq variables available in Select as `$<name>`, in addition to (and
+	// overriding) Vars of the PatchSpec
+	Vars map[string]any `yaml:"vars,omitempty" rs:"keys"`
 }
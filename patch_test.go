@@ -1,6 +1,8 @@
 package rs
 
 import (
+	"fmt"
+	"math"
 	"testing"
 
 	"arhat.dev/pkg/testhelper"
@@ -50,6 +52,73 @@ func TestMergeMap(t *testing.T) {
 	}
 }
 
+func TestJSONMergePatch(t *testing.T) {
+	// test cases from rfc7386 appendix A
+	for _, test := range []struct {
+		target, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		t.Run(test.target+test.patch, func(t *testing.T) {
+			var target, patch, expected any
+			assert.NoError(t, yaml.Unmarshal([]byte(test.target), &target))
+			assert.NoError(t, yaml.Unmarshal([]byte(test.patch), &patch))
+			assert.NoError(t, yaml.Unmarshal([]byte(test.expected), &expected))
+
+			assert.EqualValues(t, expected, JSONMergePatch(target, patch))
+		})
+	}
+}
+
+func TestMergeListByKey(t *testing.T) {
+	original := []any{
+		map[string]any{"name": "a", "value": "1"},
+		"not an object",
+		map[string]any{"value": "no key"},
+		map[string]any{"name": "b", "value": "2", "list": []any{"x"}},
+	}
+
+	ret, err := MergeListByKey(original, []any{
+		map[string]any{"name": "b", "list": []any{"y"}},
+		map[string]any{"name": "c"},
+		"not an object",
+	}, "name")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []any{
+		map[string]any{"name": "a", "value": "1"},
+		"not an object",
+		map[string]any{"value": "no key"},
+		map[string]any{"name": "b", "value": "2", "list": []any{"y"}},
+		map[string]any{"name": "c"},
+		"not an object",
+	}, ret)
+
+	// original is not modified
+	assert.EqualValues(t, []any{"x"}, original[3].(map[string]any)["list"])
+
+	_, err = MergeListByKey([]any{
+		map[string]any{"name": "a", "value": map[string]any{}},
+	}, []any{
+		map[string]any{"name": "a", "value": map[string]any{"b": "c"}},
+		map[string]any{"name": "a", "value": []any{}},
+	}, "name")
+	assert.Error(t, err)
+}
+
 func TestUniqueList(t *testing.T) {
 	mapVal := map[string]any{
 		"foo": "bar",
@@ -78,11 +147,44 @@ func TestUniqueList(t *testing.T) {
 			input:    []any{mapVal, mapVal, 1},
 			expected: []any{mapVal, 1},
 		},
+		{
+			name:     "Different Types",
+			input:    []any{1, 1.0, int64(1), "1", true, "true", nil, []any{}, []any(nil), map[string]any{}, 1},
+			expected: []any{1, 1.0, int64(1), "1", true, "true", nil, []any{}, []any(nil), map[string]any{}},
+		},
+		{
+			name: "Nested Values",
+			input: []any{
+				[]any{"a", "b"}, []any{"ab"}, []any{"a", "b"},
+				map[string]any{"a": 1, "b": []any{2}}, map[string]any{"b": []any{2}, "a": 1},
+			},
+			expected: []any{
+				[]any{"a", "b"}, []any{"ab"},
+				map[string]any{"a": 1, "b": []any{2}},
+			},
+		},
+		{
+			name:     "Negative Zero",
+			input:    []any{0.0, math.Copysign(0, -1), []any{math.Copysign(0, -1)}, []any{0.0}},
+			expected: []any{0.0, []any{math.Copysign(0, -1)}},
+		},
+		{
+			name:     "Not Encodable",
+			input:    []any{[]byte("a"), []byte("a"), math.NaN(), "a"},
+			expected: []any{[]byte("a"), math.NaN(), "a"},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			assert.EqualValues(t, test.expected, UniqueList(test.input))
+			ret := UniqueList(test.input)
+			if test.name == "Not Encodable" {
+				// NaN is not equal to itself
+				assert.Len(t, ret, len(test.expected))
+				return
+			}
+
+			assert.EqualValues(t, test.expected, ret)
 		})
 	}
 }
@@ -210,6 +312,44 @@ func TestPatchSpec_ApplyTo(t *testing.T) {
 				"a": []any{"b", "c", "a"},
 			},
 		},
+		{
+			name: "Scalar Merge Override Skips Null",
+			spec: PatchSpec{
+				Value: createPatchValue(t, "a"),
+				Merge: []MergeSource{
+					{Value: createPatchValue(t, "b")},
+					{Value: createPatchValue(t, nil)},
+				},
+				ScalarMerge: ScalarMergeOverride,
+			},
+			expected: "b",
+		},
+		{
+			name: "Scalar Merge Override Null Value",
+			spec: PatchSpec{
+				Value:       createPatchValue(t, nil),
+				Merge:       createMergeValue(t, "b"),
+				ScalarMerge: ScalarMergeOverride,
+			},
+			expected: "b",
+		},
+		{
+			name: "Invalid Scalar Merge Not Used",
+			spec: PatchSpec{
+				Value:       createPatchValue(t, map[string]any{"a": "b"}),
+				Merge:       createMergeValue(t, map[string]string{"c": "d"}),
+				ScalarMerge: "summ",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid Scalar Merge No Merge",
+			spec: PatchSpec{
+				Value:       createPatchValue(t, 1),
+				ScalarMerge: "summ",
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -226,6 +366,42 @@ func TestPatchSpec_ApplyTo(t *testing.T) {
 	}
 }
 
+func TestOptions_JQFunctions(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Foo string `yaml:"foo"`
+		Bar string `yaml:"bar"`
+	}
+
+	f := Init(&Foo{}, &Options{
+		JQFunctions: []JQFunction{{
+			Name:     "greet",
+			MinArity: 1,
+			MaxArity: 1,
+			Fn: func(v any, args []any) any {
+				return fmt.Sprint(args[0], " ", v)
+			},
+		}},
+	}).(*Foo)
+
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+foo@!:
+  value: world
+  vars: { greeting: hello }
+  select: greet($greeting)
+bar@jq(greet("hi")): there
+`), f))
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+	assert.Equal(t, "hello world", f.Foo)
+	assert.Equal(t, "hi there", f.Bar)
+
+	// not available without the option
+	f = Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`bar@jq(greet("hi")): there`), f))
+	assert.ErrorContains(t, f.ResolveFields(testRenderingHandler{}, -1), "greet/1")
+}
+
 func TestPatchSpec(t *testing.T) {
 	testAnyObjectUnmarshalAndResolveByYamlSpecs(t, "testdata/patch-spec")
 }
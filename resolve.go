@@ -2,9 +2,12 @@ package rs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 
 	"arhat.dev/pkg/stringhelper"
 	"gopkg.in/yaml.v3"
@@ -14,7 +17,16 @@ func (f *BaseField) HasUnresolvedField() bool {
 	return len(f.unresolvedNormalFields) != 0
 }
 
-func (f *BaseField) ResolveFields(rc RenderingHandler, depth int, names ...string) (err error) {
+func (f *BaseField) ResolveFields(rc RenderingHandler, depth int, names ...string) error {
+	return f.ResolveFieldsContext(context.Background(), rc, depth, names...)
+}
+
+func (f *BaseField) ResolveFieldsContext(
+	ctx context.Context,
+	rc RenderingHandler,
+	depth int,
+	names ...string,
+) (err error) {
 	if !f.initialized() {
 		err = fmt.Errorf("rs: struct not intialized before resolving")
 		return
@@ -24,43 +36,76 @@ func (f *BaseField) ResolveFields(rc RenderingHandler, depth int, names ...strin
 		return nil
 	}
 
+	err = ctx.Err()
+	if err != nil {
+		return
+	}
+
+	var (
+		collect = f.collectAllErrors()
+		errs    []error
+	)
+
+	if f.renderingTracer() != nil {
+		ctx = startTracing(ctx)
+	}
+
+	// rendered is only set when rendering concurrently
+	var rendered renderedValues
+	if f._opts != nil && f._opts.RenderingConcurrency > 1 {
+		rendered, err = f.renderConcurrently(ctx, f._opts.RenderingConcurrency, rc, names)
+		if err != nil {
+			if !collect {
+				return
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
 	if len(f.unresolvedSelfItems) != 0 {
-		err = resolveOverlappedItems(1, &f._parentValue, "", f.unresolvedSelfItems, rc)
+		err = resolveOverlappedItems(ctx, 1, "", f.unresolvedSelfItems, rendered, rc)
 		if err != nil {
-			err = fmt.Errorf("rs: resolve value from virtual key: %w", err)
-			return
+			err = wrapErrorf(err, "rs: resolve value from virtual key")
+			if !collect {
+				return
+			}
+
+			errs = append(errs, err)
 		}
 	}
 
 	if len(names) == 0 {
 		// resolve all
 
-		for name, v := range f.normalFields {
-			err = v.base.resolveNormalField(depth, &v.fieldValue, name, rc)
+		for _, name := range f.normalFieldNames(collect) {
+			v := f.normalFields[name]
+			err = v.base.resolveNormalField(
+				withTracePath(ctx, errorPathKey(name)), depth, &v.fieldValue, name, rendered, rc,
+			)
 			if err != nil {
-				err = fmt.Errorf(
-					"rs: resolve field %s.%s: %w",
-					f._parentValue.Type().String(), v.fieldName, err,
-				)
-				return
+				err = newError(f._parentValue.Type(), errorPathKey(name), nil, "", wrapErrorf(err,
+					"rs: resolve field %s.%s",
+					f._parentValue.Type().String(), v.fieldName,
+				))
+				if !collect {
+					return
+				}
+
+				errs = append(errs, err)
 			}
 		}
 
-		for k, list := range f.unresolvedInlineMapItems {
-			err = resolveOverlappedItems(depth, &f.inlineMap.fieldValue, k, list, rc)
-			if err != nil {
+		err = f.resolveInlineMapItems(ctx, depth, rendered, rc, collect)
+		if err != nil {
+			if !collect {
 				return
 			}
-		}
 
-		if f.inlineMap != nil {
-			// the inline map has been resolved above, so let's go to
-			// values of these inline map entries
-			err = handleResolvedField(depth, &f.inlineMap.fieldValue, rc)
-			return
+			errs = append(errs, err)
 		}
 
-		return
+		return joinErrors(errs)
 	}
 
 	// resolve specified fileds by tag names
@@ -77,101 +122,230 @@ func (f *BaseField) ResolveFields(rc RenderingHandler, depth int, names ...strin
 			case len(name) == 0:
 				// resolve itself (added by virtual key `__`)
 
-				err = resolveOverlappedItems(1, &f._parentValue, "", f.unresolvedSelfItems, rc)
-				if err != nil {
-					return
-				}
-
-				continue
+				err = resolveOverlappedItems(ctx, 1, "", f.unresolvedSelfItems, rendered, rc)
 			case f.inlineMap != nil && f.inlineMap.fieldName == name:
-				for k, list := range f.unresolvedInlineMapItems {
-					err = resolveOverlappedItems(depth, &f.inlineMap.fieldValue, k, list, rc)
-					if err != nil {
-						return
-					}
-				}
-
-				continue
+				err = f.resolveInlineMapItems(ctx, depth, rendered, rc, collect)
 			default:
 				err = fmt.Errorf(
 					"rs: no such field %q in struct %q",
 					name, f._parentValue.Type().String(),
 				)
+			}
+		} else {
+			err = ref.base.resolveNormalField(
+				withTracePath(ctx, errorPathKey(name)), depth, &ref.fieldValue, name, rendered, rc,
+			)
+			if err != nil {
+				err = newError(f._parentValue.Type(), errorPathKey(name), nil, "", wrapErrorf(err,
+					"rs: resolve requested field %s of %s",
+					name, f._parentValue.Type().String(),
+				))
+			}
+		}
+
+		if err != nil {
+			if !collect {
 				return
 			}
+
+			errs = append(errs, err)
 		}
+	}
+
+	return joinErrors(errs)
+}
+
+// collectAllErrors is true when Options.CollectAllErrors is set
+func (f *BaseField) collectAllErrors() bool {
+	return f != nil && f._opts != nil && f._opts.CollectAllErrors
+}
+
+// normalFieldNames returns yaml keys of all normal fields, sorted when
+// errors are collected to make the returned error deterministic
+func (f *BaseField) normalFieldNames(sorted bool) []string {
+	ret := make([]string, 0, len(f.normalFields))
+	for name := range f.normalFields {
+		ret = append(ret, name)
+	}
+
+	if sorted {
+		sort.Strings(ret)
+	}
 
-		err = ref.base.resolveNormalField(depth, &ref.fieldValue, name, rc)
+	return ret
+}
+
+// resolveInlineMapItems resolves unresolved inline map items and then values of the inline map
+func (f *BaseField) resolveInlineMapItems(
+	ctx context.Context,
+	depth int,
+	rendered renderedValues,
+	rc RenderingHandler,
+	collect bool,
+) (err error) {
+	if f.inlineMap == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(f.unresolvedInlineMapItems))
+	for k := range f.unresolvedInlineMapItems {
+		keys = append(keys, k)
+	}
+
+	if collect {
+		sort.Strings(keys)
+	}
+
+	var errs []error
+	for _, k := range keys {
+		err = resolveOverlappedItems(ctx, depth, k, f.unresolvedInlineMapItems[k], rendered, rc)
 		if err != nil {
-			err = fmt.Errorf(
-				"rs: resolve requested field %s of %s: %w",
-				name, f._parentValue.Type().String(), err,
-			)
+			if !collect {
+				return
+			}
 
+			errs = append(errs, err)
+		}
+	}
+
+	// the inline map has been resolved above, so let's go to
+	// values of these inline map entries
+	err = handleResolvedField(ctx, depth, &f.inlineMap.fieldValue, rc, collect)
+	if err != nil {
+		if !collect {
 			return
 		}
+
+		errs = append(errs, err)
 	}
 
-	return nil
+	return joinErrors(errs)
 }
 
+// resolveOverlappedItems resolves items with the same yaml key in order
+//
+// values of inline map items are not resolved here, callers should resolve
+// the inline map after all items resolved
 func resolveOverlappedItems(
+	ctx context.Context,
 	depth int,
-	fVal *reflect.Value,
 	k string,
 	list []unresolvedFieldSpec,
+	rendered renderedValues,
 	rc RenderingHandler,
 ) error {
 	var (
 		itemCache *reflect.Value
 		err       error
+		errs      []error
 	)
 
 	n := len(list)
 	for i := 0; i < n; i++ {
 		itemCache, err = handleUnresolvedField(
+			ctx,
 			depth,
 			&list[i],
 			itemCache,
 			// flush existing data with the same key on first pair
 			i != 0,
 			k,
+			rendered,
 			rc,
 		)
 		if err != nil {
-			return err
+			if !list[i].ref.base.collectAllErrors() {
+				return err
+			}
+
+			errs = append(errs, err)
 		}
 	}
 
-	return handleResolvedField(depth, fVal, rc)
+	return joinErrors(errs)
 }
 
 func (f *BaseField) resolveNormalField(
+	ctx context.Context,
 	depth int,
 	fieldValue *reflect.Value,
 	yamlKey string,
+	rendered renderedValues,
 	rc RenderingHandler,
 ) error {
 	v, ok := f.unresolvedNormalFields[yamlKey]
 	if !ok {
-		return handleResolvedField(depth, fieldValue, rc)
+		collect := f.collectAllErrors()
+
+		err := f.resolveMapKeyItems(ctx, depth, yamlKey, rendered, rc, collect)
+		if err != nil && !collect {
+			return err
+		}
+
+		return joinErrors([]error{err, handleResolvedField(ctx, depth, fieldValue, rc, collect)})
 	}
 
 	_, err := handleUnresolvedField(
-		depth, &v, nil, false, yamlKey, rc,
+		ctx, depth, &v, nil, false, yamlKey, rendered, rc,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// the field was postponed by virtual key
+	return f.resolveMapKeyItems(ctx, depth, yamlKey, rendered, rc, f.collectAllErrors())
+}
+
+// resolveMapKeyItems resolves items with rendering suffix in keys of the map field
+// with `rs:"keys"` tag
+func (f *BaseField) resolveMapKeyItems(
+	ctx context.Context,
+	depth int,
+	yamlKey string,
+	rendered renderedValues,
+	rc RenderingHandler,
+	collect bool,
+) error {
+	items := f.unresolvedMapKeyItems[yamlKey]
+	if len(items) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, k := range sortedKeys(items) {
+		err := resolveOverlappedItems(ctx, depth, k, items[k], rendered, rc)
+		if err != nil {
+			if !collect {
+				return err
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	return joinErrors(errs)
 }
 
 func handleResolvedField(
+	ctx context.Context,
 	depth int,
 	field *reflect.Value,
 	rc RenderingHandler,
+	// continue with other map/slice items on error when set
+	collect bool,
 ) (err error) {
 	if depth == 0 {
 		return
 	}
 
+	err = ctx.Err()
+	if err != nil {
+		return
+	}
+
+	// errs collected from map/slice items
+	var errs []error
+
 	switch fk := field.Kind(); fk {
 	case reflect.Map:
 		if field.IsNil() {
@@ -179,14 +353,27 @@ func handleResolvedField(
 		}
 
 		iter := field.MapRange()
-		var val reflect.Value
+		var (
+			val  reflect.Value
+			keys []string
+		)
 		for iter.Next() {
 			val = iter.Value()
-			err = handleResolvedField(depth-1, &val, rc)
+			k := fmt.Sprint(iter.Key().Interface())
+			err = handleResolvedField(withTracePath(ctx, errorPathKey(k)), depth-1, &val, rc, collect)
 			if err != nil {
-				return
+				prefixErrorPath(errorPathKey(k), err)
+				if !collect {
+					return
+				}
+
+				keys = append(keys, k)
+				errs = append(errs, err)
 			}
 		}
+
+		// map iteration is random, sort errors by map key
+		sort.Sort(&keyedErrors{keys: keys, errs: errs})
 	case reflect.Array:
 		fallthrough
 	case reflect.Slice:
@@ -202,9 +389,14 @@ func handleResolvedField(
 
 		for i := 0; i < n; i++ {
 			val = field.Index(i)
-			err = handleResolvedField(depth-1, &val, rc)
+			err = handleResolvedField(withTracePath(ctx, errorPathIndex(i)), depth-1, &val, rc, collect)
 			if err != nil {
-				return
+				prefixErrorPath(errorPathIndex(i), err)
+				if !collect {
+					return
+				}
+
+				errs = append(errs, err)
 			}
 		}
 	case reflect.Struct:
@@ -221,10 +413,19 @@ func handleResolvedField(
 		return
 	}
 
-	return tryResolve(depth-1, field, rc)
+	err = tryResolve(ctx, depth-1, field, rc)
+	if err != nil {
+		if !collect {
+			return
+		}
+
+		errs = append(errs, err)
+	}
+
+	return joinErrors(errs)
 }
 
-func tryResolve(depth int, targetField *reflect.Value, rc RenderingHandler) error {
+func tryResolve(ctx context.Context, depth int, targetField *reflect.Value, rc RenderingHandler) error {
 	if targetField.CanInterface() {
 		fVal, canCallResolve := targetField.Interface().(Field)
 		if canCallResolve {
@@ -233,7 +434,7 @@ func tryResolve(depth int, targetField *reflect.Value, rc RenderingHandler) erro
 				return nil
 			}
 
-			return fVal.ResolveFields(rc, depth)
+			return resolveField(ctx, fVal, rc, depth)
 		}
 	}
 
@@ -252,48 +453,53 @@ func tryResolve(depth int, targetField *reflect.Value, rc RenderingHandler) erro
 			return nil
 		}
 
-		return fVal.ResolveFields(rc, depth)
+		return resolveField(ctx, fVal, rc, depth)
 	}
 
 	// no more field to resolve
 	return nil
 }
 
+// resolveField calls ResolveFieldsContext when f implements ContextField
+func resolveField(ctx context.Context, f Field, rc RenderingHandler, depth int) error {
+	if cf, ok := f.(ContextField); ok {
+		return cf.ResolveFieldsContext(ctx, rc, depth)
+	}
+
+	return f.ResolveFields(rc, depth)
+}
+
 func handleUnresolvedField(
+	ctx context.Context,
 	depth int,
 	v *unresolvedFieldSpec,
 	inlineMapItemCache *reflect.Value,
 	keepOld bool,
 	yamlKey string,
+	// rendered values of unresolved fields, can be nil
+	rendered renderedValues,
 	rc RenderingHandler,
 ) (_ *reflect.Value, err error) {
 	target := v.ref
 
-	toResolve := v.rawData
-	if target.isInlineMap {
-		// unwrap map data for resolving
-		toResolve = toResolve.Content[1]
-	}
-
-	n := len(v.renderers)
-	for i := 0; i < n; i++ {
-		toResolve, err = tryRender(
-			toResolve,
-			&v.renderers[i],
-			rc,
-		)
+	resolved, ok := rendered[v.rawData]
+	switch {
+	case !ok:
+		resolved, err = renderUnresolvedField(ctx, v, yamlKey, rc)
 		if err != nil {
-			err = fmt.Errorf("render value for %q: %w", yamlKey, err)
 			return
 		}
+	case resolved == nil:
+		// failed when rendering concurrently, the error has been collected
+		return inlineMapItemCache, nil
 	}
 
-	resolved := toResolve
 	if target.isInlineMap {
 		var fm yaml.Node
 		fakeMap(&fm, v.rawData.Content[0], resolved)
 
 		inlineMapItemCache, err = unmarshalMap(
+			ctx,
 			&fm,
 			target,
 			inlineMapItemCache,
@@ -302,24 +508,95 @@ func handleUnresolvedField(
 			rc,
 		)
 	} else {
-		err = unmarshal(resolved, target, nil, nil, yamlKey, rc)
+		err = unmarshal(ctx, resolved, target, nil, nil, yamlKey, rc)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf(
-			"unmarshal resolved value of %q to field %q: %w",
-			yamlKey, target.fieldName, err,
+		// path of the field is added by the caller, while the key of
+		// inline map item has been added when unmarshaling the map
+		return nil, v.newError("", "", wrapErrorf(err,
+			"unmarshal resolved value of %q to field %q",
+			yamlKey, target.fieldName,
+		))
+	}
+
+	if target.isInlineMap {
+		// the whole inline map is resolved after all inline map items resolved
+		return inlineMapItemCache, nil
+	}
+
+	return inlineMapItemCache, handleResolvedField(
+		ctx, depth, &target.fieldValue, rc, target.base.collectAllErrors(),
+	)
+}
+
+// renderUnresolvedField renders raw data of v through all its renderers
+func renderUnresolvedField(
+	ctx context.Context,
+	v *unresolvedFieldSpec,
+	yamlKey string,
+	rc RenderingHandler,
+) (toResolve *yaml.Node, err error) {
+	toResolve = v.rawData
+	if v.ref.isInlineMap {
+		// unwrap map data for resolving
+		toResolve = toResolve.Content[1]
+	}
+
+	var (
+		ev    *RenderingEvent
+		start time.Time
+	)
+
+	n := len(v.renderers)
+	for i := 0; i < n; i++ {
+		ev = v.traceRendering(ctx, yamlKey, &v.renderers[i], toResolve)
+		if ev != nil {
+			start = time.Now()
+		}
+
+		toResolve, err = tryRender(
+			ctx,
+			toResolve,
+			&v.renderers[i],
+			rc,
+			v.ref.base._opts,
+			ev,
 		)
+
+		if ev != nil {
+			ev.Duration, ev.Err = time.Since(start), err
+			v.ref.base.renderingTracer().TraceRendering(ev)
+		}
+
+		if err != nil {
+			// inline map items are keys of the struct, while path of other
+			// fields is added by the caller
+			path := ""
+			if v.ref.isInlineMap {
+				path = errorPathKey(yamlKey)
+			}
+
+			err = v.newError(path, v.renderers[i].name, err)
+			return
+		}
 	}
 
-	return inlineMapItemCache, handleResolvedField(depth, &target.fieldValue, rc)
+	return
 }
 
 func tryRender(
+	ctx context.Context,
 	toResolve *yaml.Node,
-	rdr *rendererSpec,
+	rdr *RendererSpec,
 	rc RenderingHandler,
+	// opts is the Options of the struct having the field, can be nil
+	opts *Options,
+	// ev is set when tracing, to record output of this step
+	ev *RenderingEvent,
 ) (_ *yaml.Node, err error) {
+	strict := opts != nil && opts.StrictTypeHint
+
 	if rdr.patchSpec {
 		var (
 			patchSpec  *PatchSpec
@@ -327,53 +604,79 @@ func tryRender(
 			dataBytes  []byte
 		)
 
-		patchSpec, err = resolvePatchSpec(rc, toResolve)
+		patchSpec, err = resolvePatchSpec(ctx, rc, toResolve, opts)
 		if err != nil {
-			err = fmt.Errorf("invalid patch spec: %w", err)
+			err = wrapErrorf(err, "invalid patch spec")
 			return
 		}
 
-		patchedObj, err = patchSpec.Apply(rc)
-		if err != nil {
-			err = fmt.Errorf("apply patch: %w", err)
-			return
+		if ev != nil {
+			ev.PatchSpec = patchSpec
 		}
 
-		// patch doc is generated from arbitrary yaml data from built-in `any` value
-		// so we are able to marshal it into json with no error, and then parse the json data
-		// as *yaml.Node for further processing
-		// TODO: convert patchedObj to *yaml.Node directly
-
-		dataBytes, err = json.Marshal(patchedObj)
+		patchedObj, err = patchSpec.apply(ctx, rc)
 		if err != nil {
-			err = fmt.Errorf("marshal patched data: %w", err)
+			err = wrapErrorf(err, "apply patch")
 			return
 		}
 
-		// json data is deemed to be valid yaml, if not, we must have some
-		// big problem. so we don't recover yaml.Unmarshal from panic here
-		var tmp yaml.Node
-		err = yaml.Unmarshal(dataBytes, &tmp)
+		dataBytes, toResolve, err = toYamlNode(patchedObj, ev != nil)
 		if err != nil {
 			err = fmt.Errorf("prepare patched data: %w", err)
 			return
 		}
 
-		toResolve = &tmp
+		if ev != nil {
+			ev.Output = dataBytes
+		}
 	}
 
-	if len(rdr.name) != 0 {
+	switch {
+	case len(rdr.selectQuery) != 0:
+		var (
+			data      any
+			dataBytes []byte
+		)
+
+		if n := prepareYamlNode(toResolve); n != nil {
+			err = n.Decode(&data)
+			if err != nil {
+				err = fmt.Errorf("prepare jq input: %w", err)
+				return
+			}
+		}
+
+		data, err = runJQ(ctx, rdr.selectQuery, data, nil, opts)
+		if err != nil {
+			err = fmt.Errorf("select %q: %w", rdr.selectQuery, err)
+			return
+		}
+
+		dataBytes, toResolve, err = toYamlNode(data, ev != nil)
+		if err != nil {
+			err = fmt.Errorf("prepare selected data: %w", err)
+			return
+		}
+
+		if ev != nil {
+			ev.Output = dataBytes
+		}
+	case len(rdr.name) != 0:
 		var (
 			renderedData []byte
 			tag          string
 		)
 
-		renderedData, err = rc.RenderYaml(rdr.name, toResolve)
+		renderedData, err = renderYaml(ctx, rc, rdr.name, rdr.args, toResolve)
 		if err != nil {
-			err = fmt.Errorf("renderer %q render value: %w", rdr.name, err)
+			err = wrapErrorf(err, "renderer %q render value", rdr.name)
 			return
 		}
 
+		if ev != nil {
+			ev.Output = renderedData
+		}
+
 		// check type hinting before assuming it's valid yaml
 		//
 		// see TestResolve_yaml_unmarshal_invalid_but_no_error in resolve_test.go
@@ -409,6 +712,16 @@ func tryRender(
 	}
 
 	// apply hint after resolving (rendering)
+	if strict {
+		toResolve, err = applyHintStrict(rdr.typeHint, toResolve)
+		if err != nil {
+			err = fmt.Errorf("renderer %q type hint %q: %w", rdr.name, rdr.typeHint, err)
+			return
+		}
+
+		return toResolve, nil
+	}
+
 	toResolve, err = applyHint(rdr.typeHint, toResolve)
 	if err != nil {
 		err = fmt.Errorf("ensure type hint %q: %w", rdr.typeHint, err)
@@ -418,6 +731,33 @@ func tryRender(
 	return toResolve, nil
 }
 
+// toYamlNode converts v to *yaml.Node, the json encoding of v is also returned
+// when withJSON is set
+func toYamlNode(v any, withJSON bool) (data []byte, _ *yaml.Node, err error) {
+	if withJSON {
+		jsonValue := v
+		if n, ok := v.(*yaml.Node); ok {
+			jsonValue = nil
+			err = n.Decode(&jsonValue)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		data, err = json.Marshal(jsonValue)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ret, err := anyToYamlNode(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, ret, nil
+}
+
 func guessYamlStringStyle(s []byte) yaml.Style {
 	switch {
 	case bytes.IndexByte(s, '\n') != -1:
@@ -483,42 +823,78 @@ func applyHint(hint TypeHint, in *yaml.Node) (*yaml.Node, error) {
 	return in, nil
 }
 
+// applyHintStrict is applyHint rejecting lossy conversions, values are validated
+// against the type hint
+func applyHintStrict(hint TypeHint, in *yaml.Node) (*yaml.Node, error) {
+	if _, ok := hint.(TypeHintStr); ok {
+		if n := prepareYamlNode(in); n != nil && n.Kind != yaml.ScalarNode {
+			return in, fmt.Errorf("unexpected non scalar value for str")
+		}
+	}
+
+	ret, err := applyHint(hint, in)
+	if err != nil {
+		return ret, err
+	}
+
+	switch hint.(type) {
+	case TypeHintInt:
+		err = checkScalarValue(ret, "int", isValidIntValue)
+	case TypeHintFloat:
+		err = checkScalarValue(ret, "float", isValidFloatValue)
+	case TypeHintBool:
+		err = checkScalarValue(ret, "bool", isValidBoolValue)
+	}
+
+	return ret, err
+}
+
 // resolve user provided data as patch spec
 func resolvePatchSpec(
+	ctx context.Context,
 	rc RenderingHandler,
 	toResolve *yaml.Node,
+	// opts is the Options of the struct having the patched field, fields in
+	// the patch spec are unmarshaled and resolved with it
+	opts *Options,
 ) (ret *PatchSpec, err error) {
-	ret = Init(&PatchSpec{}, nil).(*PatchSpec)
+	ret = Init(&PatchSpec{}, opts).(*PatchSpec)
 
 	err = toResolve.Decode(ret)
 	if err != nil {
-		err = fmt.Errorf("decode patch spec: %w", err)
+		err = wrapErrorf(err, "decode patch spec")
 		return
 	}
 
-	err = ret.ResolveFields(rc, -1)
+	err = ret.ResolveFieldsContext(ctx, rc, -1)
 	if err != nil {
-		err = fmt.Errorf("resolve patch spec: %w", err)
+		err = wrapErrorf(err, "resolve patch spec")
 		return
 	}
 
 	return
 }
 
-func handleOptionalRenderingSuffixResolving(n *yaml.Node, resolve *bool, rc RenderingHandler) (any, error) {
+func handleOptionalRenderingSuffixResolving(
+	ctx context.Context,
+	n *yaml.Node,
+	resolve *bool,
+	rc RenderingHandler,
+	opts *Options,
+) (any, error) {
 	n = prepareYamlNode(n)
 	if n == nil {
 		return nil, nil
 	}
 
 	if resolve == nil || *resolve {
-		any := new(AnyObject)
+		any := Init(&AnyObject{}, opts).(*AnyObject)
 		err := n.Decode(any)
 		if err != nil {
 			return nil, err
 		}
 
-		err = any.ResolveFields(rc, -1)
+		err = any.ResolveFieldsContext(ctx, rc, -1)
 		if err != nil {
 			return nil, err
 		}
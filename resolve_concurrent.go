@@ -0,0 +1,203 @@
+package rs
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderedValues maps raw data of unresolved fields to their rendered values
+//
+// the rendered value is nil when rendering failed and the error has been collected
+type renderedValues map[*yaml.Node]*yaml.Node
+
+type renderJob struct {
+	spec    *unresolvedFieldSpec
+	yamlKey string
+
+	// path is the key path segment of normal field (including items of map field
+	// with `rs:"keys"` tag), empty for virtual key and inline map items
+	path string
+
+	// wrapErr formats error of this job in the same way as
+	// resolving fields one by one
+	wrapErr func(err error) error
+}
+
+// renderConcurrently renders unresolved fields selected by names (all fields when names is empty)
+// with at most n renderers running at the same time
+//
+// errors are reported in the order of virtual key items, normal fields and inline map items,
+// fields in the same category are sorted by their yaml keys
+func (f *BaseField) renderConcurrently(
+	ctx context.Context,
+	n int,
+	rc RenderingHandler,
+	names []string,
+) (renderedValues, error) {
+	jobs := f.collectRenderJobs(names)
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	if n > len(jobs) {
+		n = len(jobs)
+	}
+
+	var (
+		results = make([]*yaml.Node, len(jobs))
+		errs    = make([]error, len(jobs))
+		next    = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			for idx := range next {
+				job := &jobs[idx]
+				jobCtx := ctx
+				if len(job.path) != 0 {
+					jobCtx = withTracePath(ctx, job.path)
+				}
+
+				results[idx], errs[idx] = renderUnresolvedField(jobCtx, job.spec, job.yamlKey, rc)
+				if errs[idx] != nil {
+					results[idx], errs[idx] = nil, job.wrapErr(errs[idx])
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+
+	wg.Wait()
+
+	// every job fails in the same way when ctx is done
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	err := joinErrors(errs)
+	if err != nil && !f.collectAllErrors() {
+		return nil, err
+	}
+
+	// values of failed jobs are nil when collecting all errors
+	ret := make(renderedValues, len(jobs))
+	for i := range jobs {
+		ret[jobs[i].spec.rawData] = results[i]
+	}
+
+	return ret, err
+}
+
+func (f *BaseField) collectRenderJobs(names []string) (jobs []renderJob) {
+	var (
+		selfItems   bool
+		inlineItems bool
+		fieldNames  []string
+	)
+
+	if len(names) == 0 {
+		selfItems, inlineItems = true, true
+		for name := range f.normalFields {
+			fieldNames = append(fieldNames, name)
+		}
+	} else {
+		for _, name := range names {
+			switch {
+			case len(name) == 0:
+				selfItems = true
+			case f.inlineMap != nil && f.inlineMap.fieldName == name:
+				inlineItems = true
+			default:
+				fieldNames = append(fieldNames, name)
+			}
+		}
+	}
+
+	if selfItems {
+		for i := range f.unresolvedSelfItems {
+			jobs = append(jobs, renderJob{
+				spec:    &f.unresolvedSelfItems[i],
+				yamlKey: "",
+				wrapErr: func(err error) error {
+					return wrapErrorf(err, "rs: resolve value from virtual key")
+				},
+			})
+		}
+	}
+
+	sort.Strings(fieldNames)
+	for _, name := range fieldNames {
+		ref, ok := f.normalFields[name]
+		if !ok {
+			// reported when resolving
+			continue
+		}
+
+		fieldName, path := ref.fieldName, errorPathKey(name)
+		wrapErr := func(err error) error {
+			return newError(f._parentValue.Type(), path, nil, "", wrapErrorf(err,
+				"rs: resolve field %s.%s",
+				f._parentValue.Type().String(), fieldName,
+			))
+		}
+
+		spec, ok := ref.base.unresolvedNormalFields[name]
+		if ok {
+			jobs = append(jobs, renderJob{
+				spec:    &spec,
+				yamlKey: name,
+				path:    path,
+				wrapErr: wrapErr,
+			})
+		}
+
+		// items of map field with `rs:"keys"` tag, resolved after the field
+		// when it was postponed by virtual key
+		items := ref.base.unresolvedMapKeyItems[name]
+		for _, k := range sortedKeys(items) {
+			list := items[k]
+			for i := range list {
+				jobs = append(jobs, renderJob{
+					spec:    &list[i],
+					yamlKey: k,
+					path:    path,
+					wrapErr: wrapErr,
+				})
+			}
+		}
+	}
+
+	if !inlineItems {
+		return
+	}
+
+	keys := make([]string, 0, len(f.unresolvedInlineMapItems))
+	for k := range f.unresolvedInlineMapItems {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		list := f.unresolvedInlineMapItems[k]
+		for i := range list {
+			jobs = append(jobs, renderJob{
+				spec:    &list[i],
+				yamlKey: k,
+				wrapErr: func(err error) error { return err },
+			})
+		}
+	}
+
+	return
+}
@@ -1,10 +1,15 @@
 package rs
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"arhat.dev/pkg/testhelper"
 	"github.com/stretchr/testify/assert"
@@ -22,7 +27,7 @@ func TestBaseField_HasUnresolvedField(t *testing.T) {
 			isInlineMap: false,
 		},
 		nil,
-		"test", "test|data", nil,
+		nil, "test", "test|data", nil,
 	)
 	assert.True(t, f.HasUnresolvedField())
 }
@@ -97,6 +102,421 @@ func TestBaseField_ResolveField(t *testing.T) {
 	)
 }
 
+func TestBaseField_ResolveFieldsContext(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Str   string         `yaml:"str"`
+		Items []AnyObject    `yaml:"items"`
+		Other map[string]any `yaml:",inline"`
+	}
+
+	const input = `{ str@echo: a, items@echo: [b], other@echo: c }`
+
+	newFoo := func(t *testing.T) *Foo {
+		f := Init(&Foo{}, nil).(*Foo)
+		if !assert.NoError(t, yaml.Unmarshal([]byte(input), f)) {
+			t.FailNow()
+		}
+
+		return f
+	}
+
+	t.Run("plain handler", func(t *testing.T) {
+		f := newFoo(t)
+		assert.NoError(t, f.ResolveFieldsContext(context.Background(), testRenderingHandler{}, -1))
+		assert.Equal(t, "a", f.Str)
+		assert.Equal(t, "c", f.Other["other"])
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		called := false
+		f := newFoo(t)
+		err := f.ResolveFieldsContext(ctx, RenderingHandleFunc(
+			func(renderer string, rawData any) (result []byte, err error) {
+				called = true
+				return nil, nil
+			},
+		), -1)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, called)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		f := newFoo(t)
+		err := f.ResolveFieldsContext(ctx, ContextRenderingHandleFunc(
+			func(ctx context.Context, renderer string, rawData any) (result []byte, err error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		), -1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestOptions_RenderingConcurrency(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		A string `yaml:"a"`
+		B string `yaml:"b"`
+		C string `yaml:"c"`
+		D string `yaml:"d"`
+
+		Other map[string]string `yaml:",inline"`
+	}
+
+	const concurrency = 3
+
+	var (
+		running    int32
+		maxRunning int32
+		wg         sync.WaitGroup
+	)
+
+	// block until enough renderers are running
+	wg.Add(concurrency)
+	rc := RenderingHandleFunc(func(renderer string, rawData any) (result []byte, err error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			cur := atomic.LoadInt32(&maxRunning)
+			if n <= cur || atomic.CompareAndSwapInt32(&maxRunning, cur, n) {
+				break
+			}
+		}
+
+		switch renderer {
+		case "wait":
+			wg.Done()
+			wg.Wait()
+		case "err":
+			return nil, fmt.Errorf("always error")
+		}
+
+		return testRenderingHandler{}.RenderYaml("echo", rawData)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		f := Init(&Foo{}, &Options{RenderingConcurrency: concurrency}).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(`{
+			a@wait: a, b@wait: b, c@wait: c, d: d,
+			other@echo: x, other@echo: y, other@echo: z
+		}`), f))
+
+		assert.NoError(t, f.ResolveFields(rc, -1))
+		assert.EqualValues(t, concurrency, atomic.LoadInt32(&maxRunning))
+		assert.Equal(t, "a", f.A)
+		assert.Equal(t, "b", f.B)
+		assert.Equal(t, "c", f.C)
+		assert.Equal(t, "d", f.D)
+		// overlapped inline map items are applied in order
+		assert.Equal(t, map[string]string{"other": "z"}, f.Other)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var last string
+		for i := 0; i < 5; i++ {
+			f := Init(&Foo{}, &Options{RenderingConcurrency: concurrency}).(*Foo)
+			assert.NoError(t, yaml.Unmarshal([]byte(`{
+				d@err: d, a@err: a, b@echo: b, x@err: x
+			}`), f))
+
+			err := f.ResolveFields(rc, -1)
+			if !assert.Error(t, err) {
+				return
+			}
+
+			// errors are sorted
+			msg := err.Error()
+			assert.Equal(t, 3, strings.Count(msg, "always error"))
+			assert.Less(t, strings.Index(msg, "a (line"), strings.Index(msg, "d (line"))
+			assert.Less(t, strings.Index(msg, "d (line"), strings.Index(msg, "x (line"))
+			if i != 0 {
+				assert.Equal(t, last, msg)
+			}
+			last = msg
+
+			// nothing applied on error
+			assert.Empty(t, f.B)
+		}
+	})
+}
+
+func TestBaseField_renderKeys(t *testing.T) {
+	type Bar struct {
+		BaseField
+
+		Data string `yaml:"data"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Bars  map[string]*Bar   `yaml:"bars" rs:"keys"`
+		Plain map[string]string `yaml:"plain"`
+	}
+
+	const input = `
+bars:
+  a: { data: a }
+  b@echo: { data@echo: b }
+  c@echo: { data: x }
+  c@echo: { data: c }
+plain:
+  d@echo: d
+`
+
+	for _, concurrency := range []int{0, 2} {
+		opts := &Options{RenderingConcurrency: concurrency}
+
+		f := Init(&Foo{}, opts).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+		assert.Len(t, f.Bars, 1)
+
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+		if assert.Len(t, f.Bars, 3) {
+			assert.Equal(t, "a", f.Bars["a"].Data)
+			assert.Equal(t, "b", f.Bars["b"].Data)
+			// overlapped items are applied in order
+			assert.Equal(t, "c", f.Bars["c"].Data)
+		}
+
+		// not enabled
+		assert.Equal(t, map[string]string{"d@echo": "d"}, f.Plain)
+
+		f = Init(&Foo{}, opts).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte("bars: { a@err: {} }"), f))
+
+		var rsErr *Error
+		if assert.ErrorAs(t, f.ResolveFields(testRenderingHandler{}, -1), &rsErr) {
+			assert.Equal(t, "bars.a", rsErr.Path)
+			assert.Equal(t, "err", rsErr.Renderer)
+		}
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = Init(&struct {
+				BaseField
+
+				Foo []string `yaml:"foo" rs:"keys"`
+			}{}, nil)
+		})
+	})
+
+	t.Run("Virtual Key", func(t *testing.T) {
+		const input = `
+__@echo: { plain: { e: e } }
+bars:
+  a: { data: a }
+  b@echo: { data: b }
+`
+
+		for _, concurrency := range []int{0, 2} {
+			f := Init(&Foo{}, &Options{RenderingConcurrency: concurrency}).(*Foo)
+			assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+			assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+			assert.Equal(t, map[string]string{"e": "e"}, f.Plain)
+			if assert.Len(t, f.Bars, 2) {
+				assert.Equal(t, "a", f.Bars["a"].Data)
+				assert.Equal(t, "b", f.Bars["b"].Data)
+			}
+		}
+	})
+
+	t.Run("MarshalUnresolvedFields", func(t *testing.T) {
+		f := Init(&Foo{}, &Options{MarshalUnresolvedFields: true}).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+		ret, err := yaml.Marshal(f)
+		assert.NoError(t, err)
+		assert.Equal(t, `bars:
+    a:
+        data: a
+    b@echo: {data@echo: b}
+    c@echo: {data: x}
+    c@echo: {data: c}
+plain:
+    d@echo: d
+`, string(ret))
+	})
+}
+
+func TestResolve_inlineSelect(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Name  string   `yaml:"name"`
+		Names []string `yaml:"names"`
+		Count int      `yaml:"count"`
+	}
+
+	const input = `
+name@echo|jq(.items[0].name): { items: [{ name: a }, { name: b }] }
+names@echo|jq(.items[] | select(.name != "a" and .name != "(d)") | .name): { items: [{ name: a }, { name: b }, { name: c }] }
+count@jq(.items | length)?int: { items: [1, 2, 3] }
+`
+
+	for _, concurrency := range []int{0, 2} {
+		f := Init(&Foo{}, &Options{
+			RenderingConcurrency: concurrency,
+			// inline select step is always allowed
+			AllowedRenderers: map[string]struct{}{"echo": {}},
+		}).(*Foo)
+
+		assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+		assert.Equal(t, "a", f.Name)
+		assert.Equal(t, []string{"b", "c"}, f.Names)
+		assert.Equal(t, 3, f.Count)
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`name@jq(.a.b): foo`), f))
+
+	var rsErr *Error
+	if assert.ErrorAs(t, f.ResolveFields(testRenderingHandler{}, -1), &rsErr) {
+		assert.Equal(t, "name", rsErr.Path)
+		assert.Equal(t, "jq", rsErr.Renderer)
+	}
+}
+
+func TestResolve_patchedValueTypes(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Merged  yaml.Node `yaml:"merged"`
+		Patched yaml.Node `yaml:"patched"`
+	}
+
+	const input = `
+merged@!:
+  value: { float: 2.0, int: 1, str: "1" }
+  merge:
+  - value: { text: "a\nb" }
+patched@!:
+  value: { float: 1.5, int: 1 }
+  patch:
+  - { op: add, path: /large, value: 9007199254740993 }
+`
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+	for _, test := range []struct {
+		n *yaml.Node
+
+		expected map[string][2]string
+	}{
+		{
+			n: &f.Merged,
+			expected: map[string][2]string{
+				"float": {floatTag, "2.0"},
+				"int":   {intTag, "1"},
+				"str":   {strTag, "1"},
+				"text":  {strTag, "a\nb"},
+			},
+		},
+		{
+			n: &f.Patched,
+			expected: map[string][2]string{
+				"float": {floatTag, "1.5"},
+				"int":   {intTag, "1"},
+				"large": {intTag, "9007199254740993"},
+			},
+		},
+	} {
+		n := prepareYamlNode(test.n)
+		if !assert.NotNil(t, n) || !assert.Equal(t, yaml.MappingNode, n.Kind) {
+			continue
+		}
+
+		actual := make(map[string][2]string)
+		for i := 1; i < len(n.Content); i += 2 {
+			actual[n.Content[i-1].Value] = [2]string{n.Content[i].Tag, n.Content[i].Value}
+		}
+
+		assert.Equal(t, test.expected, actual)
+	}
+}
+
+func TestResolve_patchSpecOptions(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Foo map[string]any `yaml:"foo"`
+	}
+
+	resolve := func(opts *Options, input string) (*Foo, error) {
+		f := Init(&Foo{}, opts).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(input), f))
+		return f, f.ResolveFields(testRenderingHandler{}, -1)
+	}
+
+	t.Run("CollectAllErrors", func(t *testing.T) {
+		const input = `foo@!: { value: { a@err: x, b@err: y } }`
+
+		_, err := resolve(nil, input)
+		assert.Equal(t, 1, strings.Count(err.Error(), "always error"))
+
+		_, err = resolve(&Options{CollectAllErrors: true}, input)
+		assert.Equal(t, 2, strings.Count(err.Error(), "always error"))
+	})
+
+	t.Run("StrictTypeHint", func(t *testing.T) {
+		const input = `
+foo@!:
+  value:
+    a@?str: { b: c }
+`
+
+		f, err := resolve(nil, input)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "{b: c}"}, f.Foo)
+
+		_, err = resolve(&Options{StrictTypeHint: true}, input)
+		assert.ErrorContains(t, err, "foo.value.a")
+	})
+
+	t.Run("AllowedRenderers", func(t *testing.T) {
+		const input = `foo@!: { value: { a: x }, merge: [{ value@echo: { b: y } }] }`
+
+		f, err := resolve(&Options{AllowedRenderers: map[string]struct{}{"": {}, "echo": {}}}, input)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "x", "b": "y"}, f.Foo)
+
+		// the patch spec itself is allowed, but not renderers in it
+		_, err = resolve(&Options{AllowedRenderers: map[string]struct{}{"": {}}}, input)
+		assert.ErrorContains(t, err, `renderer "echo" is not allowed`)
+	})
+
+	t.Run("RenderingTracer", func(t *testing.T) {
+		rec := &RenderingRecorder{}
+		f, err := resolve(&Options{RenderingTracer: rec}, `foo@!: { value: { a@echo: x }, merge: [{ value: { b@echo: y } }] }`)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": "x", "b": "y"}, f.Foo)
+
+		renderers := make(map[string]int)
+		for _, ev := range rec.Events() {
+			renderers[ev.Renderer]++
+		}
+
+		// the patch spec itself and values in it
+		assert.Equal(t, map[string]int{"": 1, "echo": 2}, renderers)
+	})
+}
+
 func TestResolve_yaml_unmarshal_invalid_but_no_error(t *testing.T) {
 	tests := []struct {
 		dataBytes string
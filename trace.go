@@ -0,0 +1,282 @@
+package rs
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenderingTracer receives an event for every rendering step when resolving fields
+//
+// set it as Options.RenderingTracer, it MUST be safe for concurrent use when
+// Options.RenderingConcurrency is greater than 1
+type RenderingTracer interface {
+	TraceRendering(ev *RenderingEvent)
+}
+
+// RenderingTracerFunc is a helper type to wrap your function as RenderingTracer
+type RenderingTracerFunc func(ev *RenderingEvent)
+
+func (f RenderingTracerFunc) TraceRendering(ev *RenderingEvent) { f(ev) }
+
+// RenderingEvent describes one step in the rendering suffix of a yaml key
+type RenderingEvent struct {
+	// Type of the struct the yaml key belongs to
+	//
+	// can be nil when unknown
+	Type reflect.Type
+
+	// Path of the yaml key from the struct being resolved, in the same format as Error.Path
+	Path string
+
+	// Key is the yaml key without rendering suffix
+	Key string
+
+	// Renderer is the name of the renderer, empty when there is no renderer in this step
+	// (e.g. `foo@?int` or `foo@!`), `jq` for inline select step (e.g. `foo@jq(.a)`)
+	Renderer string
+
+	// TypeHint applied after rendering, nil when not set
+	TypeHint TypeHint
+
+	// Patch is true when the input was resolved as PatchSpec and applied
+	Patch bool
+
+	// PatchSpec is the resolved patch spec applied in this step, nil when Patch is false
+	// or the input failed to resolve as PatchSpec
+	PatchSpec *PatchSpec
+
+	// Input of this step
+	Input *yaml.Node
+
+	// Output is the data produced by the renderer, the json encoded selected data of
+	// inline select step, or the json encoded patched data when there is no renderer
+	// in this step
+	//
+	// it is nil when there is no renderer nor patch spec, or the step failed before
+	// generating any output
+	Output []byte
+
+	// Duration of this step
+	Duration time.Duration
+
+	// Err is the error of this step, nil when succeeded
+	Err error
+
+	// segments of Path
+	segments []string
+}
+
+// RenderingRecorder is a RenderingTracer recording all rendering events, the recorded
+// events can be dumped as a provenance tree in yaml or json format
+//
+// the zero value is ready for use
+type RenderingRecorder struct {
+	mu     sync.Mutex
+	events []RenderingEvent
+}
+
+// TraceRendering implements RenderingTracer
+func (r *RenderingRecorder) TraceRendering(ev *RenderingEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, *ev)
+	r.mu.Unlock()
+}
+
+// Events returns all events recorded in order
+func (r *RenderingRecorder) Events() []RenderingEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]RenderingEvent(nil), r.events...)
+}
+
+// Reset drops all recorded events
+func (r *RenderingRecorder) Reset() {
+	r.mu.Lock()
+	r.events = nil
+	r.mu.Unlock()
+}
+
+// Tree returns recorded events as a provenance tree
+func (r *RenderingRecorder) Tree() *ProvenanceNode {
+	root := &ProvenanceNode{}
+	for _, ev := range r.Events() {
+		node := root
+		for _, seg := range ev.segments {
+			if node.Children == nil {
+				node.Children = make(map[string]*ProvenanceNode)
+			}
+
+			child, ok := node.Children[seg]
+			if !ok {
+				child = &ProvenanceNode{}
+				node.Children[seg] = child
+			}
+
+			node = child
+		}
+
+		node.Steps = append(node.Steps, newProvenanceStep(&ev))
+	}
+
+	return root
+}
+
+func (r *RenderingRecorder) MarshalYAML() (any, error)    { return r.Tree(), nil }
+func (r *RenderingRecorder) MarshalJSON() ([]byte, error) { return json.Marshal(r.Tree()) }
+
+// ProvenanceNode is a node in the provenance tree, children are keyed by
+// segments of the key path (e.g. `foo`, `[0]`)
+type ProvenanceNode struct {
+	Steps    []ProvenanceStep           `yaml:"steps,omitempty" json:"steps,omitempty"`
+	Children map[string]*ProvenanceNode `yaml:"children,omitempty" json:"children,omitempty"`
+}
+
+// ProvenanceStep is a serializable RenderingEvent
+type ProvenanceStep struct {
+	Type      string `yaml:"type,omitempty" json:"type,omitempty"`
+	Key       string `yaml:"key" json:"key"`
+	Renderer  string `yaml:"renderer,omitempty" json:"renderer,omitempty"`
+	TypeHint  string `yaml:"type_hint,omitempty" json:"type_hint,omitempty"`
+	Patch     bool   `yaml:"patch,omitempty" json:"patch,omitempty"`
+	PatchSpec string `yaml:"patch_spec,omitempty" json:"patch_spec,omitempty"`
+	Input     string `yaml:"input" json:"input"`
+	Output    string `yaml:"output,omitempty" json:"output,omitempty"`
+	Duration  string `yaml:"duration" json:"duration"`
+	Error     string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+func newProvenanceStep(ev *RenderingEvent) (ret ProvenanceStep) {
+	ret = ProvenanceStep{
+		Key:      ev.Key,
+		Renderer: ev.Renderer,
+		Patch:    ev.Patch,
+		Output:   string(ev.Output),
+		Duration: ev.Duration.String(),
+	}
+
+	if ev.Type != nil {
+		ret.Type = ev.Type.String()
+	}
+
+	if ev.TypeHint != nil {
+		ret.TypeHint = ev.TypeHint.String()
+	}
+
+	if ev.PatchSpec != nil {
+		spec, err := yaml.Marshal(ev.PatchSpec)
+		if err == nil {
+			ret.PatchSpec = strings.TrimSuffix(string(spec), "\n")
+		}
+	}
+
+	if ev.Input != nil {
+		input, err := yaml.Marshal(ev.Input)
+		if err == nil {
+			ret.Input = strings.TrimSuffix(string(input), "\n")
+		}
+	}
+
+	if ev.Err != nil {
+		ret.Error = ev.Err.Error()
+	}
+
+	return
+}
+
+type tracePathKey struct{}
+
+// tracePath is the path of yaml key being resolved, only available when tracing
+type tracePath struct {
+	parent  *tracePath
+	segment string
+}
+
+// startTracing marks ctx for tracing key path of rendering events
+func startTracing(ctx context.Context) context.Context {
+	if isTracing(ctx) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, tracePathKey{}, (*tracePath)(nil))
+}
+
+func isTracing(ctx context.Context) bool {
+	_, ok := ctx.Value(tracePathKey{}).(*tracePath)
+	return ok
+}
+
+// withTracePath appends segment to the key path in ctx when tracing
+func withTracePath(ctx context.Context, segment string) context.Context {
+	p, ok := ctx.Value(tracePathKey{}).(*tracePath)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, tracePathKey{}, &tracePath{parent: p, segment: segment})
+}
+
+// tracePathSegments returns segments of the key path in ctx
+func tracePathSegments(ctx context.Context) (ret []string) {
+	p, _ := ctx.Value(tracePathKey{}).(*tracePath)
+	for ; p != nil; p = p.parent {
+		ret = append(ret, p.segment)
+	}
+
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+
+	return
+}
+
+// traceRendering creates an event for the rendering step about to happen,
+// it returns nil when there is no tracer
+func (v *unresolvedFieldSpec) traceRendering(
+	ctx context.Context,
+	yamlKey string,
+	rdr *RendererSpec,
+	input *yaml.Node,
+) *RenderingEvent {
+	if v.ref.base.renderingTracer() == nil {
+		return nil
+	}
+
+	segments := tracePathSegments(ctx)
+	if v.ref.isInlineMap {
+		// inline map items are keys of the struct
+		segments = append(segments, errorPathKey(yamlKey))
+	}
+
+	path := ""
+	for _, seg := range segments {
+		path = joinErrorPath(path, seg)
+	}
+
+	return &RenderingEvent{
+		Type:     v.ref.parentType(),
+		Path:     path,
+		Key:      yamlKey,
+		Renderer: rdr.name,
+		TypeHint: rdr.typeHint,
+		Patch:    rdr.patchSpec,
+		Input:    input,
+
+		segments: segments,
+	}
+}
+
+// renderingTracer returns Options.RenderingTracer if set
+func (f *BaseField) renderingTracer() RenderingTracer {
+	if f == nil || f._opts == nil {
+		return nil
+	}
+
+	return f._opts.RenderingTracer
+}
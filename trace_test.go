@@ -0,0 +1,102 @@
+package rs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderingRecorder(t *testing.T) {
+	type Item struct {
+		BaseField
+
+		Cmd string `yaml:"cmd"`
+	}
+
+	type Foo struct {
+		BaseField
+
+		Str   string            `yaml:"str"`
+		List  []string          `yaml:"list"`
+		Items []Item            `yaml:"items"`
+		Other map[string]string `yaml:",inline"`
+	}
+
+	for _, concurrency := range []int{0, 2} {
+		rec := &RenderingRecorder{}
+		f := Init(&Foo{}, &Options{
+			RenderingTracer:      rec,
+			RenderingConcurrency: concurrency,
+		}).(*Foo)
+
+		assert.NoError(t, yaml.Unmarshal([]byte(`
+str@echo: a
+list@!:
+  value: [a]
+  merge:
+  - value: [b]
+items:
+- cmd: x
+- cmd@echo|echo?str: y
+other@echo: z
+`), f))
+
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+		events := rec.Events()
+		if !assert.Len(t, events, 5) {
+			return
+		}
+
+		paths := make(map[string]int)
+		for _, ev := range events {
+			paths[ev.Path]++
+			assert.NotNil(t, ev.Input)
+			assert.NotEmpty(t, ev.Output)
+			assert.NoError(t, ev.Err)
+
+			if ev.Patch && assert.NotNil(t, ev.PatchSpec) {
+				assert.Len(t, ev.PatchSpec.Merge, 1)
+			} else {
+				assert.Nil(t, ev.PatchSpec)
+			}
+		}
+
+		assert.Equal(t, map[string]int{
+			"str": 1, "list": 1, "items[1].cmd": 2, "other": 1,
+		}, paths)
+
+		tree := rec.Tree()
+		assert.Len(t, tree.Children, 4)
+
+		steps := tree.Children["items"].Children["[1]"].Children["cmd"].Steps
+		if assert.Len(t, steps, 2) {
+			assert.Equal(t, "echo", steps[0].Renderer)
+			assert.Equal(t, "", steps[0].TypeHint)
+			assert.Equal(t, "y", steps[0].Input)
+			assert.Equal(t, "str", steps[1].TypeHint)
+			assert.Equal(t, "rs.Item", steps[1].Type)
+		}
+
+		steps = tree.Children["list"].Steps
+		if assert.Len(t, steps, 1) {
+			assert.True(t, steps[0].Patch)
+			assert.Equal(t, "", steps[0].Renderer)
+			assert.Equal(t, `["a","b"]`, steps[0].Output)
+			assert.Contains(t, steps[0].PatchSpec, "value: [a]")
+			assert.Contains(t, steps[0].PatchSpec, "merge:\n    - value: [b]")
+		}
+
+		_, err := yaml.Marshal(rec)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(rec)
+		assert.NoError(t, err)
+
+		var decoded ProvenanceNode
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+		assert.EqualValues(t, tree, &decoded)
+	}
+}
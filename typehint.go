@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"arhat.dev/pkg/stringhelper"
 	"gopkg.in/yaml.v3"
@@ -24,6 +27,13 @@ type (
 	TypeHintInt     struct{}
 	TypeHintFloat   struct{}
 	TypeHintBool    struct{}
+
+	TypeHintTimestamp struct{}
+	TypeHintBinary    struct{}
+	TypeHintNull      struct{}
+	TypeHintStrs      struct{}
+	TypeHintInts      struct{}
+	TypeHintStrMap    struct{}
 )
 
 // nolint:revive
@@ -35,6 +45,13 @@ const (
 	typeHintName_Int     = "int"
 	typeHintName_Float   = "float"
 	typeHintName_Bool    = "bool"
+
+	typeHintName_Timestamp = "timestamp"
+	typeHintName_Binary    = "binary"
+	typeHintName_Null      = "null"
+	typeHintName_Strs      = "[]str"
+	typeHintName_Ints      = "[]int"
+	typeHintName_StrMap    = "map[str]str"
 )
 
 func (TypeHintNone) String() string    { return typeHintName_None }
@@ -45,6 +62,13 @@ func (TypeHintInt) String() string     { return typeHintName_Int }
 func (TypeHintFloat) String() string   { return typeHintName_Float }
 func (TypeHintBool) String() string    { return typeHintName_Bool }
 
+func (TypeHintTimestamp) String() string { return typeHintName_Timestamp }
+func (TypeHintBinary) String() string    { return typeHintName_Binary }
+func (TypeHintNull) String() string      { return typeHintName_Null }
+func (TypeHintStrs) String() string      { return typeHintName_Strs }
+func (TypeHintInts) String() string      { return typeHintName_Ints }
+func (TypeHintStrMap) String() string    { return typeHintName_StrMap }
+
 func (TypeHintNone) apply(v *yaml.Node) (*yaml.Node, error) {
 	return prepareYamlNode(v), nil
 }
@@ -203,6 +227,189 @@ func (TypeHintBool) apply(n *yaml.Node) (*yaml.Node, error) {
 	return castScalarNode(n, boolTag)
 }
 
+// timestampLayouts are timestamp formats supported by yaml
+var timestampLayouts = []string{
+	"2006-1-2T15:4:5.999999999Z07:00", // RCF3339Nano with short date fields.
+	"2006-1-2t15:4:5.999999999Z07:00", // RFC3339Nano with short date fields and lower-case "t".
+	"2006-1-2 15:4:5.999999999",       // space separated with no time zone
+	"2006-1-2",                        // date only
+}
+
+func (TypeHintTimestamp) apply(n *yaml.Node) (*yaml.Node, error) {
+	n = prepareYamlNode(n)
+	if n == nil || isEmpty(n) {
+		return nil, nil
+	}
+
+	if n.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("unexpected non scalar node for timestamp")
+	}
+
+	if shortTag(n.Tag) != timestampTag {
+		if !isStrScalar(n) {
+			return nil, fmt.Errorf("unexpected %s value %q for timestamp", n.ShortTag(), n.Value)
+		}
+
+		var err error
+		for _, layout := range timestampLayouts {
+			_, err = time.Parse(layout, n.Value)
+			if err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", n.Value)
+		}
+	}
+
+	var ret yaml.Node
+	cloneYamlNode(&ret, n, timestampTag, n.Value)
+	return &ret, nil
+}
+
+// apply of TypeHintBinary expects base64 encoded string
+func (TypeHintBinary) apply(n *yaml.Node) (*yaml.Node, error) {
+	n = prepareYamlNode(n)
+	if n == nil || isEmpty(n) {
+		return nil, nil
+	}
+
+	switch {
+	case n.Kind != yaml.ScalarNode:
+		return nil, fmt.Errorf("unexpected non scalar node for binary")
+	case isBinaryScalar(n), isStrScalar(n):
+	default:
+		return nil, fmt.Errorf("unexpected %s value %q for binary", n.ShortTag(), n.Value)
+	}
+
+	value := trimBase64Spaces(n.Value)
+	_, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 encoded binary %q: %w", n.Value, err)
+	}
+
+	var ret yaml.Node
+	cloneYamlNode(&ret, n, binaryTag, value)
+	return &ret, nil
+}
+
+// trimBase64Spaces removes white spaces in base64 encoded binary data,
+// which are allowed in yaml `!!binary` value
+func trimBase64Spaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		default:
+			return r
+		}
+	}, s)
+}
+
+func (TypeHintNull) apply(n *yaml.Node) (*yaml.Node, error) {
+	n = prepareYamlNode(n)
+	if n != nil && !isEmpty(n) {
+		return nil, fmt.Errorf("unexpected non null %s value %q", n.ShortTag(), n.Value)
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: nullTag, Value: "null"}, nil
+}
+
+func (TypeHintStrs) apply(n *yaml.Node) (*yaml.Node, error) {
+	return applyTypedItemsHint(n, func(item *yaml.Node) (*yaml.Node, error) {
+		return scalarToStr(item)
+	})
+}
+
+func (TypeHintInts) apply(n *yaml.Node) (*yaml.Node, error) {
+	return applyTypedItemsHint(n, func(item *yaml.Node) (*yaml.Node, error) {
+		switch {
+		case item.Kind != yaml.ScalarNode:
+			return nil, fmt.Errorf("unexpected non scalar value")
+		case isIntScalar(item):
+			return item, nil
+		case isStrScalar(item):
+			_, err := strconv.ParseInt(item.Value, 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid int %q", item.Value)
+			}
+
+			var ret yaml.Node
+			cloneYamlNode(&ret, item, intTag, item.Value)
+			return &ret, nil
+		default:
+			return nil, fmt.Errorf("unexpected %s value %q for int", item.ShortTag(), item.Value)
+		}
+	})
+}
+
+func (TypeHintStrMap) apply(n *yaml.Node) (*yaml.Node, error) {
+	n, err := applyObjectHint(n)
+	if err != nil || n == nil {
+		return n, err
+	}
+
+	pairs, err := unmarshalYamlMap(n.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &yaml.Node{Kind: yaml.MappingNode, Tag: mapTag, Style: n.Style}
+	for _, kv := range pairs {
+		var key, value *yaml.Node
+		key, err = scalarToStr(kv[0])
+		if err != nil {
+			return nil, fmt.Errorf("map key: %w", err)
+		}
+
+		value, err = scalarToStr(prepareYamlNode(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("value of key %q: %w", kv[0].Value, err)
+		}
+
+		ret.Content = append(ret.Content, key, value)
+	}
+
+	return ret, nil
+}
+
+// applyTypedItemsHint converts n to a sequence node and checks all its items with fn
+func applyTypedItemsHint(n *yaml.Node, fn func(item *yaml.Node) (*yaml.Node, error)) (*yaml.Node, error) {
+	n, err := applyObjectsHint(n)
+	if err != nil || n == nil {
+		return n, err
+	}
+
+	ret := &yaml.Node{Kind: yaml.SequenceNode, Tag: seqTag, Style: n.Style}
+	for i, item := range n.Content {
+		item, err = fn(prepareYamlNode(item))
+		if err != nil {
+			return nil, fmt.Errorf("item #%d: %w", i, err)
+		}
+
+		ret.Content = append(ret.Content, item)
+	}
+
+	return ret, nil
+}
+
+// scalarToStr converts non null scalar node to str node
+func scalarToStr(n *yaml.Node) (*yaml.Node, error) {
+	switch {
+	case n == nil, n.Kind != yaml.ScalarNode:
+		return nil, fmt.Errorf("unexpected non scalar value")
+	case isNullScalar(n):
+		return nil, fmt.Errorf("unexpected null value")
+	case isStrScalar(n):
+		return n, nil
+	default:
+		var ret yaml.Node
+		cloneYamlNode(&ret, n, strTag, n.Value)
+		return &ret, nil
+	}
+}
+
 // cast scalar node directly by changing the tag of it
 func castScalarNode(n *yaml.Node, newTag string) (*yaml.Node, error) {
 	n = prepareYamlNode(n)
@@ -238,6 +445,58 @@ func castScalarNode(n *yaml.Node, newTag string) (*yaml.Node, error) {
 	return &ret, nil
 }
 
+// checkScalarValue checks value of scalar node n using isValid, null value is allowed
+func checkScalarValue(n *yaml.Node, typ string, isValid func(v string) bool) error {
+	n = prepareYamlNode(n)
+	if n == nil || isEmpty(n) {
+		return nil
+	}
+
+	if n.Kind != yaml.ScalarNode {
+		return fmt.Errorf("unexpected non scalar value for %s", typ)
+	}
+
+	if !isValid(n.Value) {
+		return fmt.Errorf("invalid %s value %q", typ, n.Value)
+	}
+
+	return nil
+}
+
+// isValidIntValue checks int value in the same way as yaml
+func isValidIntValue(v string) bool {
+	v = strings.ReplaceAll(v, "_", "")
+	if _, err := strconv.ParseInt(v, 0, 64); err == nil {
+		return true
+	}
+
+	_, err := strconv.ParseUint(v, 0, 64)
+	return err == nil
+}
+
+// isValidFloatValue checks float value in the same way as yaml
+func isValidFloatValue(v string) bool {
+	switch v {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF",
+		"-.inf", "-.Inf", "-.INF",
+		".nan", ".NaN", ".NAN":
+		return true
+	}
+
+	_, err := strconv.ParseFloat(strings.ReplaceAll(v, "_", ""), 64)
+	return err == nil || isValidIntValue(v)
+}
+
+// isValidBoolValue checks bool value as defined in yaml 1.2
+func isValidBoolValue(v string) bool {
+	switch v {
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return true
+	default:
+		return false
+	}
+}
+
 // cloneYamlNode creates a new yaml.Node by copying all values from n
 // and override its tag and value
 func cloneYamlNode(out, n *yaml.Node, tag, value string) {
@@ -263,7 +522,87 @@ func ParseTypeHint(h string) (TypeHint, error) {
 		return TypeHintFloat{}, nil
 	case typeHintName_Bool:
 		return TypeHintBool{}, nil
+	case typeHintName_Timestamp:
+		return TypeHintTimestamp{}, nil
+	case typeHintName_Binary:
+		return TypeHintBinary{}, nil
+	case typeHintName_Null:
+		return TypeHintNull{}, nil
+	case typeHintName_Strs:
+		return TypeHintStrs{}, nil
+	case typeHintName_Ints:
+		return TypeHintInts{}, nil
+	case typeHintName_StrMap:
+		return TypeHintStrMap{}, nil
 	default:
+		customTypeHints.RLock()
+		fn, ok := customTypeHints.hints[h]
+		customTypeHints.RUnlock()
+
+		if ok {
+			return TypeHintCustom{name: h, fn: fn}, nil
+		}
+
 		return nil, fmt.Errorf("unknown type hint %q", h)
 	}
 }
+
+// TypeHintFunc validates and normalizes value for a custom type hint
+//
+// the input is never nil, returning nil node keeps the input as is
+type TypeHintFunc func(n *yaml.Node) (*yaml.Node, error)
+
+// TypeHintCustom is a type hint registered by RegisterTypeHint
+type TypeHintCustom struct {
+	name string
+	fn   TypeHintFunc
+}
+
+func (h TypeHintCustom) String() string { return h.name }
+
+func (h TypeHintCustom) apply(n *yaml.Node) (*yaml.Node, error) {
+	if prepared := prepareYamlNode(n); prepared != nil {
+		n = prepared
+	}
+
+	return h.fn(n)
+}
+
+var customTypeHints struct {
+	sync.RWMutex
+
+	hints map[string]TypeHintFunc
+}
+
+// RegisterTypeHint registers a custom type hint, so it can be used in rendering suffix
+// as `?<name>` (e.g. `foo@http?duration`) to validate and normalize rendered value before
+// it's unmarshaled to the field
+//
+// it panics when name is empty, contains reserved characters (`@`, `|`, `?`, `!`, `(`, `)`, `,`)
+// or spaces, or is already used by other type hint
+func RegisterTypeHint(name string, fn func(*yaml.Node) (*yaml.Node, error)) {
+	if len(name) == 0 || strings.ContainsAny(name, "@|?!(),") || strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		panic(fmt.Errorf("rs: invalid type hint name %q", name))
+	}
+
+	if fn == nil {
+		panic(fmt.Errorf("rs: nil func for type hint %q", name))
+	}
+
+	if _, err := ParseTypeHint(name); err == nil {
+		panic(fmt.Errorf("rs: type hint %q already exists", name))
+	}
+
+	customTypeHints.Lock()
+	defer customTypeHints.Unlock()
+
+	if _, ok := customTypeHints.hints[name]; ok {
+		panic(fmt.Errorf("rs: type hint %q already exists", name))
+	}
+
+	if customTypeHints.hints == nil {
+		customTypeHints.hints = make(map[string]TypeHintFunc)
+	}
+
+	customTypeHints.hints[name] = fn
+}
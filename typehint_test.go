@@ -1,5 +1,16 @@
 package rs
 
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
 var (
 	_ TypeHint = TypeHintNone{}
 	_ TypeHint = TypeHintStr{}
@@ -8,4 +19,211 @@ var (
 	_ TypeHint = TypeHintInt{}
 	_ TypeHint = TypeHintFloat{}
 	_ TypeHint = TypeHintBool{}
+	_ TypeHint = TypeHintCustom{}
+
+	_ TypeHint = TypeHintTimestamp{}
+	_ TypeHint = TypeHintBinary{}
+	_ TypeHint = TypeHintNull{}
+	_ TypeHint = TypeHintStrs{}
+	_ TypeHint = TypeHintInts{}
+	_ TypeHint = TypeHintStrMap{}
 )
+
+func TestTypeHint_apply(t *testing.T) {
+	for _, test := range []struct {
+		hint     string
+		input    string
+		expected any
+		err      string
+	}{
+		{hint: "timestamp", input: `"2021-01-02"`, expected: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{hint: "timestamp", input: `2021-01-02T03:04:05Z`, expected: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{hint: "timestamp", input: `"01/02/2021"`, err: `invalid timestamp "01/02/2021"`},
+		{hint: "timestamp", input: `1`, err: `unexpected !!int value "1" for timestamp`},
+
+		{hint: "binary", input: `aGVsbG8=`, expected: "hello"},
+		{hint: "binary", input: "|\n  aGVs\n  bG8=", expected: "hello"},
+		{hint: "binary", input: `"hello!"`, err: `invalid base64 encoded binary "hello!"`},
+
+		{hint: "null", input: ``, expected: nil},
+		{hint: "null", input: `~`, expected: nil},
+		{hint: "null", input: `a`, err: `unexpected non null !!str value "a"`},
+
+		{hint: "[]str", input: `[a, 1, true]`, expected: []any{"a", "1", "true"}},
+		{hint: "[]str", input: `"[a, 1]"`, expected: []any{"a", "1"}},
+		{hint: "[]str", input: `[a, [b]]`, err: "item #1: unexpected non scalar value"},
+		{hint: "[]str", input: `[a, null]`, err: "item #1: unexpected null value"},
+
+		{hint: "[]int", input: `[1, "0x10"]`, expected: []any{1, 16}},
+		{hint: "[]int", input: `[1, a]`, err: `item #1: invalid int "a"`},
+		{hint: "[]int", input: `[1, 1.5]`, err: `item #1: unexpected !!float value "1.5" for int`},
+
+		{hint: "map[str]str", input: `{a: 1, b: c}`, expected: map[string]any{"a": "1", "b": "c"}},
+		{hint: "map[str]str", input: `"{a: b}"`, expected: map[string]any{"a": "b"}},
+		{hint: "map[str]str", input: `{a: {b: c}}`, err: `value of key "a": unexpected non scalar value`},
+		{hint: "map[str]str", input: `[a]`, err: "cannot convert"},
+	} {
+		t.Run(test.hint+" "+test.input, func(t *testing.T) {
+			h, err := ParseTypeHint(test.hint)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, test.hint, h.String())
+
+			var in yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(test.input), &in))
+
+			ret, err := applyHint(h, &in)
+			if len(test.err) != 0 {
+				assert.ErrorContains(t, err, test.err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var actual any
+			assert.NoError(t, ret.Decode(&actual))
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	type Foo struct {
+		BaseField
+
+		Time   time.Time         `yaml:"time"`
+		Binary []byte            `yaml:"binary"`
+		Strs   []string          `yaml:"strs"`
+		Ints   []int             `yaml:"ints"`
+		StrMap map[string]string `yaml:"str_map"`
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+time@echo?timestamp: "2021-01-02"
+binary@echo?binary: aGVsbG8=
+strs@echo?[]str: "[a, 1]"
+ints@echo?[]int: "[1, 2]"
+str_map@echo?map[str]str: "{a: 1}"
+`), f))
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+	assert.Equal(t, time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), f.Time)
+	assert.Equal(t, []byte("hello"), f.Binary)
+	assert.Equal(t, []string{"a", "1"}, f.Strs)
+	assert.Equal(t, []int{1, 2}, f.Ints)
+	assert.Equal(t, map[string]string{"a": "1"}, f.StrMap)
+}
+
+func TestRegisterTypeHint(t *testing.T) {
+	RegisterTypeHint("test-duration", func(n *yaml.Node) (*yaml.Node, error) {
+		if n.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("unexpected non scalar value")
+		}
+
+		d, err := time.ParseDuration(n.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: d.String()}, nil
+	})
+	t.Cleanup(func() { unregisterTypeHint("test-duration") })
+
+	h, err := ParseTypeHint("test-duration")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test-duration", h.String())
+	}
+
+	assert.Panics(t, func() { RegisterTypeHint("test-duration", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("int", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("a|b", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("a(b)", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("a,b", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("a b", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("a\tb", TypeHintNone{}.apply) })
+	assert.Panics(t, func() { RegisterTypeHint("", TypeHintNone{}.apply) })
+
+	type Foo struct {
+		BaseField
+
+		Timeout string `yaml:"timeout"`
+	}
+
+	f := Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`timeout@echo?test-duration: 90s`), f))
+	assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+	assert.Equal(t, "1m30s", f.Timeout)
+
+	f = Init(&Foo{}, nil).(*Foo)
+	assert.NoError(t, yaml.Unmarshal([]byte(`timeout@echo?test-duration: foo`), f))
+	assert.ErrorContains(t, f.ResolveFields(testRenderingHandler{}, -1), "test-duration")
+}
+
+func TestOptions_StrictTypeHint(t *testing.T) {
+	type Foo struct {
+		BaseField
+
+		Int   int     `yaml:"int"`
+		Float float64 `yaml:"float"`
+		Bool  bool    `yaml:"bool"`
+		Str   string  `yaml:"str"`
+	}
+
+	for _, test := range []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{name: "Int", input: `int@echo?int: 12abc`, err: `renderer "echo" type hint "int": invalid int value "12abc"`},
+		{name: "Float", input: `float@echo?float: 1.5x`, err: `renderer "echo" type hint "float": invalid float value "1.5x"`},
+		{name: "Bool", input: `bool@echo?bool: "yes"`, err: `renderer "echo" type hint "bool": invalid bool value "yes"`},
+		{name: "Str", input: `str@?str: { a: b }`, err: `renderer "" type hint "str": unexpected non scalar value for str`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := Init(&Foo{}, &Options{StrictTypeHint: true}).(*Foo)
+			assert.NoError(t, yaml.Unmarshal([]byte(test.input), f))
+
+			err := f.ResolveFields(testRenderingHandler{}, -1)
+			assert.ErrorContains(t, err, test.err)
+
+			var rsErr *Error
+			if assert.ErrorAs(t, err, &rsErr) {
+				assert.Equal(t, strings.ToLower(test.name), rsErr.Path)
+			}
+		})
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		f := Init(&Foo{}, &Options{StrictTypeHint: true}).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(`
+int@echo?int: "0x1_0"
+float@echo?float: ".inf"
+bool@echo?bool: "True"
+str@?str: 1
+`), f))
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+
+		assert.Equal(t, 16, f.Int)
+		assert.True(t, math.IsInf(f.Float, 1))
+		assert.True(t, f.Bool)
+		assert.Equal(t, "1", f.Str)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		f := Init(&Foo{}, nil).(*Foo)
+		assert.NoError(t, yaml.Unmarshal([]byte(`str@?str: { a: b }`), f))
+		assert.NoError(t, f.ResolveFields(testRenderingHandler{}, -1))
+		assert.Equal(t, "{a: b}", f.Str)
+	})
+}
+
+// unregisterTypeHint removes the custom type hint registered by RegisterTypeHint
+func unregisterTypeHint(name string) {
+	customTypeHints.Lock()
+	defer customTypeHints.Unlock()
+
+	delete(customTypeHints.hints, name)
+}
@@ -1,7 +1,9 @@
 package rs
 
 import (
+	"context"
 	"encoding/base64"
+	"fmt"
 	"reflect"
 
 	"gopkg.in/yaml.v3"
@@ -36,6 +38,18 @@ type Field interface {
 	ResolveFields(rc RenderingHandler, depth int, names ...string) error
 }
 
+// ContextField is a Field able to resolve its fields with a context.Context
+//
+// it is implemented by BaseField, when a nested Field doesn't implement it,
+// its ResolveFields method is called instead
+type ContextField interface {
+	Field
+
+	// ResolveFieldsContext is ResolveFields with a context, resolving stops
+	// as soon as possible once ctx is done, and the error of ctx is returned
+	ResolveFieldsContext(ctx context.Context, rc RenderingHandler, depth int, names ...string) error
+}
+
 type (
 	// RenderingHandler is used when resolving yaml fields
 	RenderingHandler interface {
@@ -43,6 +57,9 @@ type (
 		//
 		// renderer is the name of your renderer without type hint (`?<hint>`) and patch suffix (`!`)
 		//
+		// NOTE: `jq` is reserved for the inline select step `jq(<query>)`, which is never passed
+		// to your renderer, rendering suffix using `jq` without a query is rejected
+		//
 		// rawData is the input to your renderer, which can have one of following types
 		// - golang primitive types (e.g. int, float32)
 		// - map[string]any
@@ -63,6 +80,94 @@ func (f RenderingHandleFunc) RenderYaml(renderer string, rawData any) (result []
 	return f(renderer, rawData)
 }
 
+type (
+	// ContextRenderingHandler is a RenderingHandler aware of context.Context
+	//
+	// when the RenderingHandler passed to ResolveFieldsContext also implements
+	// this interface, RenderYamlContext is called instead of RenderYaml
+	ContextRenderingHandler interface {
+		// RenderYamlContext is RenderYaml with a context, your renderer SHOULD
+		// return as soon as ctx is done
+		RenderYamlContext(ctx context.Context, renderer string, rawData any) (result []byte, err error)
+	}
+
+	// ContextRenderingHandleFunc is a helper type to wrap your function as ContextRenderingHandler
+	//
+	// it also implements RenderingHandler by calling the function with context.Background()
+	ContextRenderingHandleFunc func(ctx context.Context, renderer string, rawData any) (result []byte, err error)
+)
+
+func (f ContextRenderingHandleFunc) RenderYaml(renderer string, rawData any) (result []byte, err error) {
+	return f(context.Background(), renderer, rawData)
+}
+
+func (f ContextRenderingHandleFunc) RenderYamlContext(
+	ctx context.Context, renderer string, rawData any,
+) (result []byte, err error) {
+	return f(ctx, renderer, rawData)
+}
+
+type (
+	// ArgsRenderingHandler is a RenderingHandler accepting renderer arguments
+	//
+	// when the RenderingHandler passed to ResolveFields also implements this interface,
+	// RenderYamlArgs is called instead of RenderYamlContext and RenderYaml
+	ArgsRenderingHandler interface {
+		// RenderYamlArgs is RenderYamlContext with renderer arguments set in the rendering
+		// suffix (e.g. `foo@http(timeout=5s,cache)`), args is nil when there is no argument
+		RenderYamlArgs(ctx context.Context, renderer string, args RendererArgs, rawData any) (result []byte, err error)
+	}
+
+	// ArgsRenderingHandleFunc is a helper type to wrap your function as ArgsRenderingHandler
+	//
+	// it also implements RenderingHandler and ContextRenderingHandler by calling the function
+	// with nil args
+	ArgsRenderingHandleFunc func(
+		ctx context.Context, renderer string, args RendererArgs, rawData any,
+	) (result []byte, err error)
+)
+
+func (f ArgsRenderingHandleFunc) RenderYaml(renderer string, rawData any) (result []byte, err error) {
+	return f(context.Background(), renderer, nil, rawData)
+}
+
+func (f ArgsRenderingHandleFunc) RenderYamlContext(
+	ctx context.Context, renderer string, rawData any,
+) (result []byte, err error) {
+	return f(ctx, renderer, nil, rawData)
+}
+
+func (f ArgsRenderingHandleFunc) RenderYamlArgs(
+	ctx context.Context, renderer string, args RendererArgs, rawData any,
+) (result []byte, err error) {
+	return f(ctx, renderer, args, rawData)
+}
+
+// renderYaml calls RenderYamlArgs when rc implements ArgsRenderingHandler, or RenderYamlContext
+// when rc implements ContextRenderingHandler, otherwise RenderYaml, which can only be cancelled
+// before it's called
+//
+// it's an error to have args when rc doesn't implement ArgsRenderingHandler
+func renderYaml(ctx context.Context, rc RenderingHandler, renderer string, args RendererArgs, rawData any) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if arc, ok := rc.(ArgsRenderingHandler); ok {
+		return arc.RenderYamlArgs(ctx, renderer, args, rawData)
+	}
+
+	if len(args) != 0 {
+		return nil, fmt.Errorf("renderer arguments not supported by the rendering handler")
+	}
+
+	if crc, ok := rc.(ContextRenderingHandler); ok {
+		return crc.RenderYamlContext(ctx, renderer, rawData)
+	}
+
+	return rc.RenderYaml(renderer, rawData)
+}
+
 type (
 	// InterfaceTypeHandler is used when setting values for any typed field
 	InterfaceTypeHandler interface {
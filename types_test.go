@@ -1,6 +1,7 @@
 package rs
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -11,6 +12,13 @@ import (
 var (
 	_ RenderingHandler = RenderingHandleFunc(nil)
 
+	_ RenderingHandler        = ContextRenderingHandleFunc(nil)
+	_ ContextRenderingHandler = ContextRenderingHandleFunc(nil)
+
+	_ RenderingHandler        = ArgsRenderingHandleFunc(nil)
+	_ ContextRenderingHandler = ArgsRenderingHandleFunc(nil)
+	_ ArgsRenderingHandler    = ArgsRenderingHandleFunc(nil)
+
 	_ InterfaceTypeHandler = InterfaceTypeHandleFunc(nil)
 )
 
@@ -39,6 +47,47 @@ func TestRenderingHandler_RenderYaml(t *testing.T) {
 	assert.True(t, called)
 }
 
+func TestContextRenderingHandleFunc_RenderYaml(t *testing.T) {
+	type ctxKey struct{}
+
+	var got context.Context
+	f := ContextRenderingHandleFunc(func(ctx context.Context, renderer string, rawData any) (result []byte, err error) {
+		got = ctx
+		assert.EqualValues(t, "test", renderer)
+		assert.EqualValues(t, "rawData", rawData)
+		return nil, nil
+	})
+
+	_, _ = f.RenderYaml("test", "rawData")
+	assert.Equal(t, context.Background(), got)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	_, _ = renderYaml(ctx, f, "test", nil, "rawData")
+	assert.Equal(t, ctx, got)
+
+	_, err := renderYaml(ctx, f, "test", RendererArgs{{Key: "a"}}, "rawData")
+	assert.Error(t, err)
+}
+
+func TestArgsRenderingHandleFunc_RenderYaml(t *testing.T) {
+	var got RendererArgs
+	f := ArgsRenderingHandleFunc(func(
+		ctx context.Context, renderer string, args RendererArgs, rawData any,
+	) (result []byte, err error) {
+		got = args
+		assert.EqualValues(t, "test", renderer)
+		assert.EqualValues(t, "rawData", rawData)
+		return nil, nil
+	})
+
+	_, _ = f.RenderYaml("test", "rawData")
+	assert.Nil(t, got)
+
+	args := RendererArgs{{Key: "timeout", Value: "5s"}, {Key: "cache"}}
+	_, _ = renderYaml(context.Background(), f, "test", args, "rawData")
+	assert.Equal(t, args, got)
+}
+
 func TestNormalizeRawData(t *testing.T) {
 	for _, test := range []struct {
 		name     string
@@ -1,6 +1,8 @@
 package rs
 
 import (
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"reflect"
@@ -16,16 +18,24 @@ func (f *BaseField) UnmarshalYAML(n *yaml.Node) (err error) {
 	}
 
 	if n.Kind != yaml.MappingNode {
-		return fmt.Errorf("rs: unexpected non map data %q for struct %q unmarshaling",
+		return newError(f._parentValue.Type(), "", n, "", fmt.Errorf(
+			"rs: unexpected non map data %q for struct %q unmarshaling",
 			n.Tag, f._parentValue.Type().String(),
-		)
+		))
+	}
+
+	if f._opts != nil && f._opts.KeepSourceNode {
+		f.sourceNode = n
+	} else {
+		f.sourceNode = nil
 	}
 
 	oneLevelMap, err := unmarshalYamlMap(n.Content)
 	if err != nil {
-		return fmt.Errorf("rs: data unmarshal failed for %s: %w",
+		return newError(f._parentValue.Type(), "", n, "", fmt.Errorf(
+			"rs: data unmarshal failed for %s: %w",
 			f._parentValue.Type().String(), err,
-		)
+		))
 	}
 
 	var (
@@ -36,6 +46,8 @@ func (f *BaseField) UnmarshalYAML(n *yaml.Node) (err error) {
 		field       fieldRef
 
 		rawYamlKey, rsTag, yamlKey string
+
+		errs []error
 	)
 	// set values
 	for _, kv := range oneLevelMap {
@@ -43,7 +55,7 @@ func (f *BaseField) UnmarshalYAML(n *yaml.Node) (err error) {
 
 		// custom tag with `!rs:` prefix can also indicate rendering suffix
 
-		suffixStart = strings.LastIndexByte(rawYamlKey, '@')
+		suffixStart = renderingSuffixStart(rawYamlKey)
 		field, hasField = f.getField(rawYamlKey)
 		rsTag = strings.TrimPrefix(strings.TrimPrefix(kv[1].Tag, "!rs:"), "!tag:arhat.dev/rs:")
 
@@ -56,7 +68,11 @@ func (f *BaseField) UnmarshalYAML(n *yaml.Node) (err error) {
 			// In this case, user MUST NOT set @ style rendering suffix
 			// at the same time
 
-			kv[1].Tag = ""
+			// clear the tag in a copy to keep the source node untouched
+			value := *kv[1]
+			value.Tag = ""
+			kv = &[2]*yaml.Node{kv[0], &value}
+
 			yamlKey = rawYamlKey
 			if yamlKey == "__" {
 				hasVirtualKey = true
@@ -98,11 +114,17 @@ func (f *BaseField) UnmarshalYAML(n *yaml.Node) (err error) {
 		}
 
 		if err != nil {
-			return
+			// path of the yaml key has been added to err if it is known
+			err = newError(f._parentValue.Type(), "", kv[0], "", err)
+			if !f.collectAllErrors() {
+				return err
+			}
+
+			errs = append(errs, err)
 		}
 	}
 
-	return
+	return joinErrors(errs)
 }
 
 func (f *BaseField) unmarshalNoRS(yamlKey string, kv *[2]*yaml.Node, field *fieldRef) (err error) {
@@ -120,20 +142,40 @@ func (f *BaseField) unmarshalNoRS(yamlKey string, kv *[2]*yaml.Node, field *fiel
 			return
 		}
 
-		err = fmt.Errorf("rs: unknown yaml field %q to %s",
+		err = newError(f._parentValue.Type(), errorPathKey(yamlKey), kv[0], "", fmt.Errorf(
+			"rs: unknown yaml field %q to %s",
 			yamlKey, f._parentValue.Type().String(),
-		)
+		))
 		return
 	}
 
+	if field.renderKeys {
+		// items with rendering suffix in keys are resolved later
+		v, err = field.base.addUnresolvedMapKeyItems(field, yamlKey, v)
+		if err != nil {
+			return
+		}
+	}
+
 	// field is not nil, fill value into inline map
 	//
 	// it's safe to provide nil RenderingHandler as we don't have any rendering suffix
-	err = unmarshal(v, field, &field.isInlineMap, nil, yamlKey, nil /* rendering handler */)
+	err = unmarshal(
+		context.Background(),
+		v, field, &field.isInlineMap, nil, yamlKey,
+		nil, /* rendering handler */
+	)
 	if err != nil {
-		err = fmt.Errorf("rs: unmarshal yaml field %q to type %s.%s: %w",
-			yamlKey, f._parentValue.Type().String(), field.fieldName, err,
-		)
+		path := errorPathKey(yamlKey)
+		if field.isInlineMap {
+			// the key has been added to the path when unmarshaling the map
+			path = ""
+		}
+
+		err = newError(f._parentValue.Type(), path, kv[0], "", wrapErrorf(err,
+			"rs: unmarshal yaml field %q to type %s.%s",
+			yamlKey, f._parentValue.Type().String(), field.fieldName,
+		))
 		return
 	}
 
@@ -156,7 +198,7 @@ func (f *BaseField) unmarshalRS(yamlKey, suffix string, kv *[2]*yaml.Node) (err
 	if ref == nil {
 		if yamlKey == "__" {
 			// handle virtual key
-			return f.addUnresolvedField_self(suffix, kv[1])
+			return f.addUnresolvedField_self(suffix, kv[0], kv[1])
 		}
 
 		var (
@@ -175,25 +217,44 @@ func (f *BaseField) unmarshalRS(yamlKey, suffix string, kv *[2]*yaml.Node) (err
 			return nil
 		}
 
-		err = fmt.Errorf("rs: unknown yaml key %q for type %s",
+		err = newError(f._parentValue.Type(), errorPathKey(yamlKey), kv[0], "", fmt.Errorf(
+			"rs: unknown yaml key %q for type %s",
 			yamlKey, f._parentValue.Type().String(),
-		)
+		))
 		return
 	}
 
 	// field is not nil
 
 	if ref.disableRS {
-		err = fmt.Errorf("rendering suffix is not allowed to %q (type %s)",
+		err = newError(f._parentValue.Type(), errorPathKey(yamlKey), kv[0], "", fmt.Errorf(
+			"rendering suffix is not allowed to %q (type %s)",
 			yamlKey, f._parentValue.Type().String(),
-		)
+		))
 		return
 	}
 
-	return ref.base.addUnresolvedField(ref, v, yamlKey, suffix, nil)
+	if ref.renderKeys {
+		if len(suffix) == 0 {
+			// postponed by virtual key, items with rendering suffix in keys
+			// are resolved after the field
+			v, err = ref.base.addUnresolvedMapKeyItems(ref, yamlKey, v)
+			if err != nil {
+				return
+			}
+		} else {
+			// keys in the rendered value are used as is
+			delete(ref.base.unresolvedMapKeyItems, yamlKey)
+		}
+	}
+
+	return ref.base.addUnresolvedField(ref, v, kv[0], yamlKey, suffix, nil)
 }
 
 func unmarshal(
+	// ctx is only used when resolving virtual key for rendered data
+	ctx context.Context,
+
 	in *yaml.Node,
 	out *fieldRef,
 	keepOld *bool,
@@ -287,12 +348,17 @@ func unmarshal(
 			}
 
 			ufs = unresolvedFieldSpec{
-				ref:       out,
-				rawData:   pair[1],
-				renderers: parseRenderingSuffix(suffix),
+				ref:     out,
+				rawData: pair[1],
+				key:     pair[0],
 			}
 
-			_, err = handleUnresolvedField(1, &ufs, nil, true, yamlKey, rc)
+			ufs.renderers, err = parseRenderingSuffix(suffix)
+			if err != nil {
+				return newError(out.parentType(), "", pair[0], "", err)
+			}
+
+			_, err = handleUnresolvedField(ctx, 1, &ufs, nil, true, yamlKey, nil, rc)
 			if err != nil {
 				return err
 			}
@@ -326,16 +392,20 @@ func unmarshal(
 		err = fmt.Errorf("invalid out value is not data type for yaml key %q", yamlKey)
 		return
 	case reflect.Array:
-		return unmarshalArray(in, out, yamlKey, rc)
+		return unmarshalArray(ctx, in, out, yamlKey, rc)
 	case reflect.Slice:
-		return unmarshalSlice(in, out, keepOld, yamlKey, rc)
+		if isBinaryScalar(in) && out.fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			return unmarshalBinary(in, out, yamlKey)
+		}
+
+		return unmarshalSlice(ctx, in, out, keepOld, yamlKey, rc)
 	case reflect.Map:
-		_, err = unmarshalMap(in, out, nil, keepOld, yamlKey, rc)
+		_, err = unmarshalMap(ctx, in, out, nil, keepOld, yamlKey, rc)
 		return
 	case reflect.Struct:
 		return unmarshalStruct(in, out, yamlKey)
 	case reflect.Interface:
-		handled, err := unmarshalInterface(in, out, keepOld, yamlKey, rc)
+		handled, err := unmarshalInterface(ctx, in, out, keepOld, yamlKey, rc)
 		if !handled {
 			// fallback to go-yaml behavior
 			return in.Decode(out.fieldValue.Addr().Interface())
@@ -402,6 +472,7 @@ func unmarshalStruct(
 
 // unmarshalInterface handles interface type creation
 func unmarshalInterface(
+	ctx context.Context,
 	in *yaml.Node,
 	out *fieldRef,
 	keepOld *bool,
@@ -444,10 +515,11 @@ func unmarshalInterface(
 
 	// DO NOT use outVal directly, which will always match reflect.Interface
 	clonedOut := out.clone(val)
-	return true, unmarshal(in, &clonedOut, keepOld, in, yamlKey, rc)
+	return true, unmarshal(ctx, in, &clonedOut, keepOld, in, yamlKey, rc)
 }
 
 func unmarshalArray(
+	ctx context.Context,
 	in *yaml.Node,
 	out *fieldRef,
 	yamlKey string,
@@ -473,10 +545,14 @@ func unmarshalArray(
 		)
 	}
 
-	var clonedOut fieldRef
+	var (
+		clonedOut fieldRef
+		errs      []error
+	)
 	for i := 0; i < expectedSize; i++ {
 		clonedOut = out.clone(out.fieldValue.Index(i))
 		err = unmarshal(
+			ctx,
 			in.Content[i], &clonedOut,
 			// always drop existing inner data
 			// (actually doesn't matter since it's new)
@@ -486,19 +562,24 @@ func unmarshalArray(
 			rc,
 		)
 		if err != nil {
-			err = fmt.Errorf(
-				"unmarshal #%d array item of yaml field %q for %s: %w",
-				i, yamlKey, out.fieldValue.Type().String(), err,
-			)
-			return
+			err = newError(out.parentType(), errorPathIndex(i), in.Content[i], "", wrapErrorf(err,
+				"unmarshal #%d array item of yaml field %q for %s",
+				i, yamlKey, out.fieldValue.Type().String(),
+			))
+			if !out.base.collectAllErrors() {
+				return
+			}
+
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	return joinErrors(errs)
 }
 
 // when keepOld is set to true, append data from in to original slice
 func unmarshalSlice(
+	ctx context.Context,
 	in *yaml.Node,
 	outVal *fieldRef,
 	keepOld *bool,
@@ -524,10 +605,14 @@ func unmarshalSlice(
 	size := len(in.Content)
 	tmpVal := reflect.MakeSlice(outVal.fieldValue.Type(), size, size)
 
-	var clonedOut fieldRef
+	var (
+		clonedOut fieldRef
+		errs      []error
+	)
 	for i := 0; i < size; i++ {
 		clonedOut = outVal.clone(tmpVal.Index(i))
 		err = unmarshal(
+			ctx,
 			in.Content[i], &clonedOut,
 			// always drop existing inner data
 			// (actually doesn't matter since it's new)
@@ -537,10 +622,15 @@ func unmarshalSlice(
 			rc,
 		)
 		if err != nil {
-			return fmt.Errorf(
-				"unmarshal #%d slice item of yaml field %q for %s: %w",
-				i, yamlKey, outVal.fieldValue.Type().String(), err,
-			)
+			err = newError(outVal.parentType(), errorPathIndex(i), in.Content[i], "", wrapErrorf(err,
+				"unmarshal #%d slice item of yaml field %q for %s",
+				i, yamlKey, outVal.fieldValue.Type().String(),
+			))
+			if !outVal.base.collectAllErrors() {
+				return
+			}
+
+			errs = append(errs, err)
 		}
 	}
 
@@ -555,12 +645,13 @@ func unmarshalSlice(
 		outVal.fieldValue.Set(reflect.AppendSlice(outVal.fieldValue, tmpVal))
 	}
 
-	return
+	return joinErrors(errs)
 }
 
 // map key MUST be string
 // the return value is only meaningful when keepOld is set (resolving inline map pair)
 func unmarshalMap(
+	ctx context.Context,
 	in *yaml.Node,
 	outVal *fieldRef,
 	inlineMapItemCache *reflect.Value,
@@ -597,6 +688,7 @@ func unmarshalMap(
 	var (
 		k         string
 		clonedOut fieldRef
+		errs      []error
 	)
 	for i, kv := range m {
 		if i == 0 && keepOld != nil && *keepOld && inlineMapItemCache != nil {
@@ -609,23 +701,41 @@ func unmarshalMap(
 		k = kv[0].Value
 		clonedOut = outVal.clone(*ret)
 		err = unmarshal(
+			ctx,
 			kv[1], &clonedOut, keepOld, in,
 			// use k rather than `yamlKey`
 			k,
 			rc,
 		)
 		if err != nil {
-			err = fmt.Errorf("unmarshal map value %s for key %q: %w",
-				valType.String(), k, err,
-			)
+			err = newError(outVal.parentType(), errorPathKey(k), kv[0], "", wrapErrorf(err,
+				"unmarshal map value %s for key %q",
+				valType.String(), k,
+			))
 
-			return
+			if !outVal.base.collectAllErrors() {
+				return
+			}
+
+			errs = append(errs, err)
+			continue
 		}
 
 		outVal.fieldValue.SetMapIndex(reflect.ValueOf(k), *ret)
 	}
 
-	return
+	return ret, joinErrors(errs)
+}
+
+// unmarshalBinary sets base64 decoded data of `!!binary` value to byte slice
+func unmarshalBinary(in *yaml.Node, out *fieldRef, yamlKey string) error {
+	data, err := base64.StdEncoding.DecodeString(trimBase64Spaces(in.Value))
+	if err != nil {
+		return fmt.Errorf("invalid binary value for %q: %w", yamlKey, err)
+	}
+
+	out.fieldValue.Set(reflect.ValueOf(data).Convert(out.fieldValue.Type()))
+	return nil
 }
 
 func checkAssignable(yamlKey string, in, out reflect.Value) (err error) {
@@ -247,7 +247,7 @@ bool_ptr: null
 								fieldValue: reflect.Value{},
 							},
 							rawData:   fakeScalarNode("bar"),
-							renderers: []rendererSpec{{name: "add-suffix-test"}},
+							renderers: []RendererSpec{{name: "add-suffix-test"}},
 						},
 					},
 				},
@@ -275,7 +275,7 @@ bool_ptr: null
 									isInlineMap: true,
 								},
 								rawData:   fakeMapPtr(fakeScalarNode("other"), fakeScalarNode("foo")),
-								renderers: []rendererSpec{{name: "echo"}},
+								renderers: []RendererSpec{{name: "echo"}},
 							},
 							{
 								ref: &fieldRef{
@@ -285,7 +285,7 @@ bool_ptr: null
 									isInlineMap: true,
 								},
 								rawData:   fakeMapPtr(fakeScalarNode("other"), fakeScalarNode("bar")),
-								renderers: []rendererSpec{{name: "echo"}},
+								renderers: []RendererSpec{{name: "echo"}},
 							},
 						},
 					},
@@ -319,7 +319,7 @@ bool_ptr: null
 								isInlineMap: true,
 							},
 							rawData:   fakeMapPtr(fakeScalarNode("other_field_1"), fakeScalarNode("foo")),
-							renderers: []rendererSpec{{name: "echo"}},
+							renderers: []RendererSpec{{name: "echo"}},
 						}},
 						"other_field_2": {{
 							ref: &fieldRef{
@@ -329,7 +329,7 @@ bool_ptr: null
 								isInlineMap: true,
 							},
 							rawData:   fakeMapPtr(fakeScalarNode("other_field_2"), fakeScalarNode("bar")),
-							renderers: []rendererSpec{{name: "add-suffix-test"}},
+							renderers: []RendererSpec{{name: "add-suffix-test"}},
 						}},
 					},
 				},
@@ -373,7 +373,7 @@ array@echo|echo|echo: |-
 									fieldValue: reflect.Value{},
 								},
 								rawData: fakeScalarNode("c: e"),
-								renderers: []rendererSpec{
+								renderers: []RendererSpec{
 									{name: "echo"},
 									{name: "echo"},
 								},
@@ -389,7 +389,7 @@ array@echo|echo|echo: |-
 - "3"
 - "4"
 - '5'`),
-								renderers: []rendererSpec{
+								renderers: []RendererSpec{
 									{name: "echo"},
 									{name: "echo"},
 									{name: "echo"},
@@ -433,7 +433,7 @@ delegated_array@echo|echo|echo: |-
 								fieldValue: reflect.Value{},
 							},
 							rawData: fakeScalarNode("c: e"),
-							renderers: []rendererSpec{
+							renderers: []RendererSpec{
 								{name: "echo"},
 								{name: "echo"},
 							},
@@ -449,7 +449,7 @@ delegated_array@echo|echo|echo: |-
 - "3"
 - "4"
 - '5'`),
-							renderers: []rendererSpec{
+							renderers: []RendererSpec{
 								{name: "echo"},
 								{name: "echo"},
 								{name: "echo"},
@@ -686,16 +686,22 @@ func cleanupBaseField(t *testing.T, f *BaseField) {
 	f._parentValue = reflect.Value{}
 	f.normalFields = nil
 	f.inlineMap = nil
-	for k := range f.unresolvedNormalFields {
-		f.unresolvedNormalFields[k].ref.base = nil
-		f.unresolvedNormalFields[k].ref.fieldValue = reflect.Value{}
-		cleanupYamlNode(f.unresolvedNormalFields[k].rawData)
+	f.fieldKeys = nil
+	f.inlineMapIndex = 0
+	f.sourceNode = nil
+	for k, v := range f.unresolvedNormalFields {
+		v.ref.base = nil
+		v.ref.fieldValue = reflect.Value{}
+		v.key = nil
+		cleanupYamlNode(v.rawData)
+		f.unresolvedNormalFields[k] = v
 	}
 	for _, list := range f.unresolvedInlineMapItems {
-		for _, v := range list {
-			v.ref.base = nil
-			v.ref.fieldValue = reflect.Value{}
-			cleanupYamlNode(v.rawData)
+		for i := range list {
+			list[i].ref.base = nil
+			list[i].ref.fieldValue = reflect.Value{}
+			list[i].key = nil
+			cleanupYamlNode(list[i].rawData)
 		}
 	}
 }
@@ -1,7 +1,15 @@
 package rs
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -133,3 +141,140 @@ func isBoolScalar(n *yaml.Node) bool   { return checkScalarType(n, boolTag) }
 func isNullScalar(n *yaml.Node) bool   { return checkScalarType(n, nullTag) }
 func isBinaryScalar(n *yaml.Node) bool { return checkScalarType(n, binaryTag) }
 func isStrScalar(n *yaml.Node) bool    { return checkScalarType(n, strTag) }
+
+// anyToYamlNode converts v to *yaml.Node directly, with scalar types kept as tags
+// (e.g. float values stay floats even if integral, []byte values are encoded as !!binary)
+//
+// values not produced by yaml/json/jq decoding are encoded by yaml.v3
+func anyToYamlNode(v any) (*yaml.Node, error) {
+	switch t := v.(type) {
+	case nil:
+		return newScalarNode(nullTag, "null"), nil
+	case *yaml.Node:
+		return t, nil
+	case bool:
+		return newScalarNode(boolTag, strconv.FormatBool(t)), nil
+	case string:
+		n := newScalarNode(strTag, t)
+		n.Style = guessYamlStringStyle([]byte(t))
+		return n, nil
+	case int:
+		return newScalarNode(intTag, strconv.FormatInt(int64(t), 10)), nil
+	case int8:
+		return newScalarNode(intTag, strconv.FormatInt(int64(t), 10)), nil
+	case int16:
+		return newScalarNode(intTag, strconv.FormatInt(int64(t), 10)), nil
+	case int32:
+		return newScalarNode(intTag, strconv.FormatInt(int64(t), 10)), nil
+	case int64:
+		return newScalarNode(intTag, strconv.FormatInt(t, 10)), nil
+	case uint:
+		return newScalarNode(intTag, strconv.FormatUint(uint64(t), 10)), nil
+	case uint8:
+		return newScalarNode(intTag, strconv.FormatUint(uint64(t), 10)), nil
+	case uint16:
+		return newScalarNode(intTag, strconv.FormatUint(uint64(t), 10)), nil
+	case uint32:
+		return newScalarNode(intTag, strconv.FormatUint(uint64(t), 10)), nil
+	case uint64:
+		return newScalarNode(intTag, strconv.FormatUint(t, 10)), nil
+	case *big.Int:
+		return newScalarNode(intTag, t.String()), nil
+	case float32:
+		return newScalarNode(floatTag, formatYamlFloat(float64(t), 32)), nil
+	case float64:
+		return newScalarNode(floatTag, formatYamlFloat(t, 64)), nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return newScalarNode(intTag, t.String()), nil
+		}
+
+		f, err := t.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid json number %q: %w", t.String(), err)
+		}
+
+		return newScalarNode(floatTag, formatYamlFloat(f, 64)), nil
+	case []byte:
+		return newScalarNode(binaryTag, base64.StdEncoding.EncodeToString(t)), nil
+	case time.Time:
+		return newScalarNode(timestampTag, t.Format(time.RFC3339Nano)), nil
+	case []any:
+		ret := &yaml.Node{
+			Kind:    yaml.SequenceNode,
+			Tag:     seqTag,
+			Content: make([]*yaml.Node, len(t)),
+		}
+
+		for i, item := range t {
+			n, err := anyToYamlNode(item)
+			if err != nil {
+				return nil, fmt.Errorf("list item #%d: %w", i, err)
+			}
+
+			ret.Content[i] = n
+		}
+
+		return ret, nil
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		ret := &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Tag:     mapTag,
+			Content: make([]*yaml.Node, 0, 2*len(t)),
+		}
+
+		for _, k := range keys {
+			n, err := anyToYamlNode(t[k])
+			if err != nil {
+				return nil, fmt.Errorf("map value of key %q: %w", k, err)
+			}
+
+			ret.Content = append(ret.Content, newScalarNode(strTag, k), n)
+		}
+
+		return ret, nil
+	default:
+		// keys of map[any]any and custom types are left to yaml.v3
+		ret := new(yaml.Node)
+		err := ret.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return ret, nil
+	}
+}
+
+func newScalarNode(tag, value string) *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   tag,
+		Value: value,
+	}
+}
+
+// formatYamlFloat formats f as yaml float value, integral values are suffixed with `.0`
+// to be resolved as float again
+func formatYamlFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
@@ -1,7 +1,9 @@
 package rs
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
@@ -93,3 +95,52 @@ non_scalar:
 		})
 	}
 }
+
+func TestAnyToYamlNode(t *testing.T) {
+	ts := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	for _, test := range []struct {
+		name  string
+		input any
+
+		expected string
+	}{
+		{"Null", nil, "null\n"},
+		{"Bool", true, "true\n"},
+		{"Int", int64(-1), "-1\n"},
+		{"Uint", uint64(math.MaxUint64), "18446744073709551615\n"},
+		{"Float Integral", 2.0, "2.0\n"},
+		{"Float", float32(1.5), "1.5\n"},
+		{"Float Exponent", 1e21, "1e+21\n"},
+		{"Float Inf", math.Inf(-1), "-.inf\n"},
+		{"String Like Int", "1", "\"1\"\n"},
+		{"String Multiline", "a\nb\n", "|\n    a\n    b\n"},
+		{"Binary", []byte("foo"), "!!binary Zm9v\n"},
+		{"Timestamp", ts, "2021-01-02T03:04:05.000000006Z\n"},
+		{"List", []any{1, "a", nil}, "- 1\n- a\n- null\n"},
+		{"Map Sorted", map[string]any{"b": 1.0, "a": []any{}}, "a: []\nb: 1.0\n"},
+		{"Fallback", map[any]any{1: "a"}, "1: a\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := anyToYamlNode(test.input)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			data, err := yaml.Marshal(n)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(data))
+
+			// values decoded back should be the same type as input
+			var ret any
+			assert.NoError(t, n.Decode(&ret))
+			switch test.input.(type) {
+			case float32, map[any]any:
+			case []byte:
+				assert.Equal(t, "foo", ret)
+			default:
+				assert.EqualValues(t, test.input, ret)
+			}
+		})
+	}
+}